@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+)
+
+// BasicAuth 指定 Basic 认证的账号和密码
+func (req *Request) BasicAuth(username, password string) *Request {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return req.Header("Authorization", "Basic "+auth)
+}
+
+// BearerToken 指定 Bearer 认证的令牌
+func (req *Request) BearerToken(token string) *Request {
+	return req.Header("Authorization", "Bearer "+token)
+}
+
+// APIKey 以报头 name 的形式指定 API key
+func (req *Request) APIKey(name, key string) *Request { return req.Header(name, key) }
+
+// APIKeyQuery 以查询参数 name 的形式指定 API key
+//
+// name 会被添加到 [Request.RedactQuery] 中。
+func (req *Request) APIKeyQuery(name, key string) *Request {
+	q := req.dumper.redactQuery
+	req.dumper.redactQuery = append(q[:len(q):len(q)], name) // 不修改与 Server 共用的底层数组
+	return req.Query(name, key)
+}
+
+// Sign 指定对请求进行签名的函数
+//
+// f 会在每次发送请求之前调用，此时请求的报头、查询参数等已经全部生成，
+// 其参数分别为将要发送的请求以及报文内容，可以在 f 中修改请求的报头等内容。
+// 多次调用会按顺序依次执行。
+func (req *Request) Sign(f func(r *http.Request, body []byte) error) *Request {
+	req.signers = append(req.signers, f)
+	return req
+}
+
+// HMACSigner 生成 HMAC 签名函数
+//
+// 签名的内容为以换行符分隔的请求方法、请求地址的路径和查询参数以及报文内容，
+// 签名结果以十六进制的形式写入报头 header 中。h 为哈希算法，比如 [sha256.New]。
+// 返回值可以作为 [Request.Sign] 的参数。
+func HMACSigner(header string, key []byte, h func() hash.Hash) func(*http.Request, []byte) error {
+	return func(r *http.Request, body []byte) error {
+		mac := hmac.New(h, key)
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n"))
+		mac.Write(body)
+		r.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestRequest_Auth(t *testing.T) {
+	a := assert.New(t, false)
+
+	r := Get(a, "/users").BasicAuth("admin", "123").Request()
+	username, password, ok := r.BasicAuth()
+	a.True(ok).Equal(username, "admin").Equal(password, "123")
+
+	r = Get(a, "/users").BearerToken("token").Request()
+	a.Equal(r.Header.Get("Authorization"), "Bearer token")
+
+	r = Get(a, "/users").APIKey("X-API-Key", "key").APIKeyQuery("api_key", "qkey").Request()
+	a.Equal(r.Header.Get("X-API-Key"), "key").
+		Equal(r.URL.Query().Get("api_key"), "qkey")
+}
+
+func TestRequest_Sign(t *testing.T) {
+	a := assert.New(t, false)
+	key := []byte("secret")
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + r.Header.Get("X-Time")))
+		sign, err := hex.DecodeString(r.Header.Get("X-Signature"))
+		if err != nil || !hmac.Equal(sign, mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := NewServer(a, h, nil)
+
+	var cnt int
+	req := srv.Post("/users", nil).
+		Query("page", "1").
+		Sign(func(r *http.Request, body []byte) error { // 每次请求都会重新执行
+			cnt++
+			r.Header.Set("X-Time", strconv.Itoa(cnt))
+			return nil
+		}).
+		Sign(func(r *http.Request, body []byte) error {
+			return HMACSigner("X-Signature", key, sha256.New)(r, []byte(r.Header.Get("X-Time")))
+		})
+	req.Do(nil).Status(http.StatusOK)
+	req.Do(nil).Status(http.StatusOK)
+	a.Equal(cnt, 2)
+
+	srv.Post("/users", []byte("body")).
+		Sign(HMACSigner("X-Signature", []byte("invalid"), sha256.New)).
+		Do(nil).
+		Status(http.StatusUnauthorized)
+
+	_, err := Get(a, "/users").Sign(func(*http.Request, []byte) error { return errors.New("sign error") }).request()
+	a.ErrorString(err, "sign error")
+}
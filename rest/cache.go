@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/issue9/assert/v4"
+)
+
+// CacheControl 解析之后的 Cache-Control 报头
+//
+// 键名为小写的指令名称，值为指令的参数，没有参数的指令其值为空字符串。
+type CacheControl map[string]string
+
+// ParseCacheControl 解析 Cache-Control 报头的值
+//
+// 无法识别的内容会被忽略。
+func ParseCacheControl(v string) CacheControl {
+	cc := CacheControl{}
+
+	for v != "" {
+		// 指令名称
+		i := strings.IndexAny(v, ",=")
+		if i < 0 {
+			i = len(v)
+		}
+		name := strings.ToLower(strings.TrimSpace(v[:i]))
+		v = v[i:]
+
+		// 指令参数
+		var val string
+		if strings.HasPrefix(v, "=") {
+			v = strings.TrimLeft(v[1:], " \t")
+			if strings.HasPrefix(v, `"`) {
+				val, v = readQuoted(v[1:])
+			} else {
+				i := strings.IndexByte(v, ',')
+				if i < 0 {
+					i = len(v)
+				}
+				val, v = strings.TrimSpace(v[:i]), v[i:]
+			}
+		}
+
+		if name != "" {
+			cc[name] = val
+		}
+
+		// 跳过之后的分隔符
+		if i := strings.IndexByte(v, ','); i >= 0 {
+			v = v[i+1:]
+		} else {
+			v = ""
+		}
+	}
+
+	return cc
+}
+
+// 读取引号中的内容，v 不包含起始的引号，返回内容以及剩余部分。
+func readQuoted(v string) (string, string) {
+	b := strings.Builder{}
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\':
+			if i+1 < len(v) {
+				i++
+				b.WriteByte(v[i])
+			}
+		case '"':
+			return b.String(), v[i+1:]
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), ""
+}
+
+// Has 是否包含指令 directive
+func (cc CacheControl) Has(directive string) bool {
+	_, found := cc[strings.ToLower(directive)]
+	return found
+}
+
+// Seconds 返回以秒为单位的指令 directive 的值
+//
+// 比如 max-age、s-maxage 等，如果指令不存在或是值无法解析，返回 false。
+func (cc CacheControl) Seconds(directive string) (int, bool) {
+	v, found := cc[strings.ToLower(directive)]
+	if !found {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// CacheControl 返回解析之后的 Cache-Control 报头
+//
+// 多个 Cache-Control 报头会被合并。
+func (resp *Response) CacheControl() CacheControl {
+	return ParseCacheControl(strings.Join(resp.resp.Header.Values("Cache-Control"), ","))
+}
+
+// CacheDirective 断言 Cache-Control 报头中包含指令 directive
+//
+// 比如 no-store、private 等。
+func (resp *Response) CacheDirective(directive string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	cc := resp.CacheControl()
+	return resp.assert(cc.Has(directive), assert.NewFailure("CacheDirective", msg, map[string]interface{}{"cache-control": cc, "directive": directive}))
+}
+
+// NotCacheDirective 断言 Cache-Control 报头中不包含指令 directive
+func (resp *Response) NotCacheDirective(directive string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	cc := resp.CacheControl()
+	return resp.assert(!cc.Has(directive), assert.NewFailure("NotCacheDirective", msg, map[string]interface{}{"cache-control": cc, "directive": directive}))
+}
+
+// CacheMaxAge 断言 Cache-Control 报头中 max-age 的值为 seconds
+func (resp *Response) CacheMaxAge(seconds int, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	cc := resp.CacheControl()
+	n, found := cc.Seconds("max-age")
+	return resp.assert(found && n == seconds, assert.NewFailure("CacheMaxAge", msg, map[string]interface{}{"cache-control": cc, "val": seconds}))
+}
+
+// NotModified 断言状态码为 304 且内容为空
+func (resp *Response) NotModified(msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	ok := resp.resp.StatusCode == http.StatusNotModified && len(resp.body) == 0
+	return resp.assert(ok, assert.NewFailure("NotModified", msg, map[string]interface{}{"status": resp.resp.StatusCode, "body": string(resp.body)}))
+}
+
+// Conditional 根据 resp 添加条件请求的报头
+//
+// resp 中的 ETag 和 Last-Modified 报头会分别作为 If-None-Match 和 If-Modified-Since 报头的值，
+// 可用于验证服务端对缓存的处理：
+//
+//	resp := srv.Get("/users/1").Do(nil).Status(http.StatusOK)
+//	srv.Get("/users/1").Conditional(resp).Do(nil).NotModified()
+func (req *Request) Conditional(resp *Response) *Request {
+	if etag := resp.resp.Header.Get("ETag"); etag != "" {
+		req.Header("If-None-Match", etag)
+	}
+	if lm := resp.resp.Header.Get("Last-Modified"); lm != "" {
+		req.Header("If-Modified-Since", lm)
+	}
+	return req
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.Equal(ParseCacheControl(""), CacheControl{})
+	a.Equal(ParseCacheControl("no-store"), CacheControl{"no-store": ""})
+	a.Equal(ParseCacheControl(`Private, MAX-AGE=60 , s-maxage = 30, no-cache="Set-Cookie, X-Test",,ext="a\"b"`), CacheControl{
+		"private":  "",
+		"max-age":  "60",
+		"s-maxage": "30",
+		"no-cache": "Set-Cookie, X-Test",
+		"ext":      `a"b`,
+	})
+
+	cc := ParseCacheControl("public, max-age=60, s-maxage=abc")
+	a.True(cc.Has("Public")).False(cc.Has("private"))
+	n, ok := cc.Seconds("max-age")
+	a.True(ok).Equal(n, 60)
+	_, ok = cc.Seconds("s-maxage")
+	a.False(ok)
+	_, ok = cc.Seconds("no-store")
+	a.False(ok)
+}
+
+func TestResponse_Cache(t *testing.T) {
+	a := assert.New(t, false)
+
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "private, max-age=60")
+		w.Header().Add("Cache-Control", "must-revalidate")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "data.txt", lastModified, strings.NewReader("data"))
+	})
+	srv := NewServer(a, h, nil)
+
+	resp := srv.Get("/data").Do(nil).
+		Status(http.StatusOK).
+		StringBody("data").
+		CacheDirective("private").
+		CacheDirective("must-revalidate").
+		NotCacheDirective("no-store").
+		CacheMaxAge(60)
+	a.Equal(resp.CacheControl(), CacheControl{"private": "", "max-age": "60", "must-revalidate": ""})
+
+	srv.Get("/data").Conditional(resp).Do(nil).NotModified()
+
+	r := srv.Get("/data").Conditional(resp).Request()
+	a.Equal(r.Header.Get("If-None-Match"), `"v1"`).
+		Equal(r.Header.Get("If-Modified-Since"), lastModified.Format(http.TimeFormat))
+
+	// 仅 If-Modified-Since
+	resp.Resp().Header.Del("ETag")
+	srv.Get("/data").Conditional(resp).Do(nil).NotModified()
+
+	srv.Get("/data").Header("If-None-Match", `"v2"`).Do(nil).Status(http.StatusOK)
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// HonorCancel 断言 h 能正确响应请求的取消操作
+//
+// 以当前请求访问 h，在 after 之后取消请求，h 必须在取消之后的 within 时间内返回。
+// 如果 h 在取消之前已经返回，也被视为断言成功。
+func (req *Request) HonorCancel(h http.Handler, after, within time.Duration, msg ...interface{}) *Request {
+	if h == nil {
+		panic("h 不能为空")
+	}
+
+	req.a.TB().Helper()
+
+	r := req.Request()
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}()
+
+	select {
+	case <-done:
+		return req
+	case <-time.After(after):
+		cancel()
+	}
+
+	select {
+	case <-done:
+	case <-time.After(within):
+		req.a.Assert(false, assert.NewFailure("HonorCancel", msg, map[string]interface{}{"after": after, "within": within}))
+	}
+	return req
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+var waitHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case <-time.After(time.Second):
+		w.WriteHeader(http.StatusOK)
+	}
+})
+
+func TestRequest_Context(t *testing.T) {
+	a := assert.New(t, false)
+
+	type key int
+	ctx := context.WithValue(context.Background(), key(1), "v")
+	r := Get(a, "/").Context(ctx).Request()
+	a.Equal(r.Context().Value(key(1)), "v")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Get(a, "/").Context(ctx).Do(waitHandler).Status(http.StatusServiceUnavailable)
+
+	srv := NewServer(a, waitHandler, nil)
+	_, err := srv.Get("/").Context(ctx).do(nil)
+	a.ErrorIs(err, context.Canceled)
+}
+
+func TestRequest_Timeout(t *testing.T) {
+	a := assert.New(t, false)
+
+	Get(a, "/").Timeout(10 * time.Millisecond).Do(waitHandler).Status(http.StatusServiceUnavailable)
+
+	srv := NewServer(a, waitHandler, nil, Timeout(10*time.Millisecond))
+	_, err := srv.Get("/").do(nil)
+	a.True(errors.Is(err, context.DeadlineExceeded))
+
+	// 单个请求可以覆盖默认值
+	srv.Get("/").Timeout(0).Do(nil).Status(http.StatusOK)
+}
+
+func TestRequest_HonorCancel(t *testing.T) {
+	a := assert.New(t, false)
+
+	Get(a, "/").HonorCancel(waitHandler, 10*time.Millisecond, 100*time.Millisecond)
+	Get(a, "/").HonorCancel(BuildHandler(a, http.StatusOK, "", nil), time.Second, time.Millisecond)
+
+	a.Panic(func() {
+		Get(a, "/").HonorCancel(nil, time.Second, time.Second)
+	})
+
+	// 忽略了 r.Context() 的处理函数
+	release := make(chan struct{})
+	defer close(release)
+	ignore := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	})
+	tb := &failTB{TB: t}
+	Get(assert.New(tb, false), "/").HonorCancel(ignore, 10*time.Millisecond, 20*time.Millisecond)
+	errs := tb.finish()
+	a.Length(errs, 1).Contains(errs[0], "HonorCancel")
+}
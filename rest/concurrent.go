@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Responses 一组请求的返回结果
+type Responses struct {
+	a     *assert.Assertion
+	resps []*Response
+}
+
+// Concurrent 并发执行 n 次当前请求
+//
+// h 的作用与 [Request.Do] 中的相同。每次请求都会重新构建请求内容，
+// 所以 [Request.Body] 等指定的内容在每次请求中都是完整的。
+func (req *Request) Concurrent(n int, h http.Handler) *Responses {
+	if n <= 0 {
+		panic("参数 n 必须大于 0")
+	}
+
+	req.a.TB().Helper()
+
+	reqs := make([]*Request, n)
+	for i := range reqs {
+		reqs[i] = req
+	}
+	return Concurrent(req.a, h, reqs...)
+}
+
+// Concurrent 并发执行 reqs 中的所有请求
+//
+// 所有请求会尽可能地同时发出，可用于检测处理函数中的数据竞争和锁的问题。
+// h 的作用与 [Request.Do] 中的相同；
+// 返回值中的顺序与 reqs 相同，请求出错的项会断言失败，且其值为 nil。
+func Concurrent(a *assert.Assertion, h http.Handler, reqs ...*Request) *Responses {
+	for _, req := range reqs {
+		if req.client == nil && h == nil {
+			panic("h 不能为空")
+		}
+	}
+
+	a.TB().Helper()
+
+	resps := make([]*Response, len(reqs))
+	errs := make([]error, len(reqs))
+	start := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req *Request) {
+			defer wg.Done()
+			<-start
+			resps[i], errs[i] = req.do(h)
+		}(i, req)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		a.NotError(err, "第 %d 个请求出错", i)
+		if err != nil {
+			resps[i] = nil
+		}
+	}
+
+	return &Responses{a: a, resps: resps}
+}
+
+// All 返回所有的 [Response]
+func (r *Responses) All() []*Response {
+	resps := make([]*Response, len(r.resps))
+	copy(resps, r.resps)
+	return resps
+}
+
+// Each 依次对每一个 [Response] 调用 f
+//
+// 出错的请求会被忽略。
+func (r *Responses) Each(f func(*Response)) *Responses {
+	r.a.TB().Helper()
+
+	for _, resp := range r.resps {
+		if resp != nil {
+			f(resp)
+		}
+	}
+	return r
+}
+
+// Success 断言所有请求的状态码都在 100-399 之间
+func (r *Responses) Success(msg ...interface{}) *Responses {
+	r.a.TB().Helper()
+	return r.Each(func(resp *Response) { resp.Success(msg...) })
+}
+
+// Status 断言所有请求的状态码都为 status
+func (r *Responses) Status(status int, msg ...interface{}) *Responses {
+	r.a.TB().Helper()
+	return r.Each(func(resp *Response) { resp.Status(status, msg...) })
+}
+
+// Unique 断言所有请求通过 f 计算的值各不相同
+//
+// 比如可以用于判断并发创建的资源 ID 是否唯一：
+//
+//	r.Unique(func(resp *rest.Response) string { return resp.Resp().Header.Get("Location") })
+func (r *Responses) Unique(f func(*Response) string, msg ...interface{}) *Responses {
+	r.a.TB().Helper()
+
+	exists := make(map[string]int, len(r.resps))
+	var dup []string
+	r.Each(func(resp *Response) {
+		v := f(resp)
+		if exists[v]++; exists[v] == 2 {
+			dup = append(dup, v)
+		}
+	})
+
+	r.a.Assert(len(dup) == 0, assert.NewFailure("Unique", msg, map[string]interface{}{"duplicate": dup}))
+	return r
+}
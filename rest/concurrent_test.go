@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestConcurrent(t *testing.T) {
+	a := assert.New(t, false)
+
+	var mu sync.Mutex
+	var id int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || string(body) != `{"name":"n"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		id++
+		curr := id
+		mu.Unlock()
+
+		w.Header().Set("Location", "/users/"+strconv.Itoa(curr))
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv := NewServer(a, h, nil)
+
+	r := srv.Post("/users", []byte(`{"name":"n"}`)).
+		Concurrent(20, nil).
+		Success().
+		Status(http.StatusCreated).
+		Unique(func(resp *Response) string { return resp.Resp().Header.Get("Location") })
+	a.Length(r.All(), 20).Equal(id, 20)
+	srv.ReceivedTimes(http.MethodPost, "/users", 20)
+
+	r = Concurrent(a, h,
+		Post(a, "/users", []byte(`{"name":"n"}`)),
+		Post(a, "/users", []byte(`{"name":"x"}`)),
+	)
+	all := r.All()
+	a.Length(all, 2)
+	all[0].Status(http.StatusCreated)
+	all[1].Status(http.StatusBadRequest)
+
+	var cnt int
+	r.Each(func(*Response) { cnt++ })
+	a.Equal(cnt, 2)
+
+	a.Panic(func() {
+		Get(a, "/users").Concurrent(0, h)
+	})
+	a.Panic(func() {
+		Concurrent(a, nil, Get(a, "/users"))
+	})
+}
@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Conn 直接向服务写入原始数据的连接
+//
+// 与 [RawHTTP] 不同，写入的内容不经过任何解析和处理，
+// 可用于测试服务对格式错误的请求、重复的 Content-Length、请求走私以及慢速写入等情况的处理。
+type Conn struct {
+	a    *assert.Assertion
+	conn net.Conn
+	br   *bufio.Reader
+	once sync.Once
+
+	dumper dumper
+}
+
+// Dial 建立与当前服务的连接
+//
+// 如果是由 [NewTLSServer] 创建的服务，会建立 TLS 连接。
+// 连接会在 [Conn.Close] 或是 testing.TB.Cleanup 中关闭。
+func (srv *Server) Dial() *Conn {
+	srv.a.TB().Helper()
+
+	addr := srv.server.Listener.Addr().String()
+
+	var conn net.Conn
+	var err error
+	if srv.server.TLS != nil {
+		conn, err = tls.Dial("tcp", addr, srv.clientTLSConfig())
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	srv.a.NotError(err).NotNil(conn)
+
+	c := &Conn{a: srv.a, conn: conn, br: bufio.NewReader(conn), dumper: srv.dumper}
+	srv.a.TB().Cleanup(c.Close)
+	return c
+}
+
+// Write 写入原始数据
+func (c *Conn) Write(data []byte) *Conn {
+	c.a.TB().Helper()
+	_, err := c.conn.Write(data)
+	c.a.NotError(err)
+	return c
+}
+
+// WriteString 写入原始数据
+func (c *Conn) WriteString(s string) *Conn {
+	c.a.TB().Helper()
+	return c.Write([]byte(s))
+}
+
+// WriteSlowly 将 data 按 chunk 字节分块，每隔 interval 写入一块
+//
+// 可用于模拟 slowloris 之类的慢速请求。
+// 如果服务端在写入过程中关闭了连接，会在写入失败时停止写入，且不会断言失败。
+func (c *Conn) WriteSlowly(data []byte, chunk int, interval time.Duration) *Conn {
+	if chunk <= 0 {
+		panic("chunk 必须大于 0")
+	}
+
+	for len(data) > 0 {
+		size := chunk
+		if size > len(data) {
+			size = len(data)
+		}
+
+		if _, err := c.conn.Write(data[:size]); err != nil {
+			return c
+		}
+		data = data[size:]
+
+		if len(data) > 0 {
+			time.Sleep(interval)
+		}
+	}
+	return c
+}
+
+// CloseWrite 关闭连接的写入端
+//
+// 服务端将读取到 EOF，但依然可以读取服务端的返回内容。
+func (c *Conn) CloseWrite() *Conn {
+	c.a.TB().Helper()
+
+	type closeWriter interface{ CloseWrite() error }
+	c.a.NotError(c.conn.(closeWriter).CloseWrite())
+	return c
+}
+
+// Response 读取并解析下一个返回内容
+//
+// 返回的对象可以使用 [Response] 的所有断言方法，但不包含请求的相关信息。
+// 如果在 timeout 时间内未读取到完整的返回内容，断言失败并返回 nil。
+func (c *Conn) Response(timeout time.Duration) *Response {
+	c.a.TB().Helper()
+
+	resp, body, err := c.readResponse(timeout)
+	c.a.NotError(err)
+	if err != nil {
+		return nil
+	}
+
+	return &Response{a: c.a, resp: resp, body: body, dumper: c.dumper}
+}
+
+// Expect 读取下一个返回内容并与 respRaw 进行比较
+//
+// respRaw 的格式及比较规则与 [RawHTTP] 相同。
+func (c *Conn) Expect(timeout time.Duration, respRaw string) *Conn {
+	c.a.TB().Helper()
+
+	exp, err := http.ReadResponse(bufio.NewReader(bytes.NewBufferString(respRaw)), nil)
+	c.a.NotError(err).NotNil(exp)
+
+	resp, body, err := c.readResponse(timeout)
+	c.a.NotError(err)
+	if err == nil {
+		compare(c.a, exp, resp.StatusCode, resp.Header, bytes.NewReader(body))
+	}
+	return c
+}
+
+func (c *Conn) readResponse(timeout time.Duration) (*http.Response, []byte, error) {
+	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, nil, err
+	}
+	defer c.conn.SetReadDeadline(time.Time{})
+
+	resp, err := http.ReadResponse(c.br, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, resp.Body.Close()
+}
+
+// ReadAll 读取服务端返回的所有原始数据，直到服务端关闭连接
+//
+// 如果在 timeout 时间内服务端未关闭连接，断言失败，并返回已经读取的数据。
+func (c *Conn) ReadAll(timeout time.Duration) []byte {
+	c.a.TB().Helper()
+
+	c.a.NotError(c.conn.SetReadDeadline(time.Now().Add(timeout)))
+	defer c.conn.SetReadDeadline(time.Time{})
+
+	data, err := io.ReadAll(c.br)
+	if err != nil && !isPeerClosed(err) {
+		c.a.NotError(err, "未在 %s 内关闭连接", timeout)
+	}
+	return data
+}
+
+// ExpectClosed 断言服务端在 timeout 时间内关闭了连接且没有再返回任何数据
+func (c *Conn) ExpectClosed(timeout time.Duration, msg ...interface{}) *Conn {
+	c.a.TB().Helper()
+
+	c.a.NotError(c.conn.SetReadDeadline(time.Now().Add(timeout)))
+	defer c.conn.SetReadDeadline(time.Time{})
+
+	data, err := io.ReadAll(c.br)
+	closed := err == nil || isPeerClosed(err)
+	c.a.Assert(closed && len(data) == 0, assert.NewFailure("ExpectClosed", msg, map[string]interface{}{"err": err, "data": string(data)}))
+	return c
+}
+
+// 服务端在关闭连接时如果还有未读取的数据，客户端读取到的可能是 RST 而不是 EOF。
+func isPeerClosed(err error) bool {
+	for _, errno := range connResetErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close 关闭连接
+//
+// 如果未手动调用，则在 testing.TB.Cleanup 中自动调用。
+func (c *Conn) Close() {
+	c.once.Do(func() {
+		if err := c.conn.Close(); !errors.Is(err, net.ErrClosed) {
+			c.a.NotError(err)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestServer_Dial(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil)
+
+	// 同一连接上的多个请求
+	c := srv.Dial().
+		WriteString("GET /get HTTP/1.1\r\nHost: example.com\r\n\r\n").
+		WriteString("POST /body HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 8\r\n\r\n{\"id\":5}")
+	c.Expect(time.Second, "HTTP/1.1 201\r\nDate: {{any}}\r\n\r\n")
+	c.Response(time.Second).Status(http.StatusCreated).StringBody(`{"id":6}`)
+	c.Close()
+
+	// 格式错误的请求行
+	c = srv.Dial().WriteString("GET\r\n\r\n")
+	c.Response(time.Second).Status(http.StatusBadRequest)
+	c.ExpectClosed(time.Second)
+
+	// 重复且不相同的 Content-Length
+	c = srv.Dial().WriteString("POST /body HTTP/1.1\r\nHost: example.com\r\nContent-Length: 8\r\nContent-Length: 9\r\n\r\n{\"id\":5}")
+	data := c.ReadAll(time.Second)
+	a.True(strings.HasPrefix(string(data), "HTTP/1.1 400 "), string(data))
+
+	// 关闭写入端
+	srv.Dial().WriteString("GET /get HTTP/1.0\r\n\r\n").CloseWrite().
+		Expect(time.Second, "HTTP/1.0 201\r\n\r\n").
+		ExpectClosed(time.Second)
+
+	a.Panic(func() {
+		srv.Dial().WriteSlowly([]byte("GET"), 0, time.Millisecond)
+	})
+}
+
+func TestConn_WriteSlowly(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil, func(s *Server) {
+		s.server.Config.ReadHeaderTimeout = 100 * time.Millisecond
+	})
+
+	srv.Dial().
+		WriteSlowly([]byte("GET /get HTTP/1.1\r\nHost: example.com\r\n\r\n"), 10, time.Millisecond).
+		Expect(time.Second, "HTTP/1.1 201\r\n\r\n")
+
+	// 超过 ReadHeaderTimeout 之后由服务端关闭连接
+	srv.Dial().
+		WriteSlowly([]byte("GET /get HTTP/1.1\r\n"), 5, 80*time.Millisecond).
+		ReadAll(time.Second)
+}
+
+func TestTLSServer_Dial(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewTLSServer(a, h, nil)
+
+	srv.Dial().
+		WriteString("GET /get HTTP/1.1\r\nHost: example.com\r\n\r\n").
+		Expect(time.Second, "HTTP/1.1 201\r\n\r\n")
+}
+
+func TestIsPeerClosed(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.True(isPeerClosed(&net.OpError{Op: "read", Err: os.NewSyscallError("read", connResetErrnos[0])})).
+		False(isPeerClosed(&net.OpError{Op: "read", Err: net.ErrClosed})).
+		False(isPeerClosed(&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded})).
+		False(isPeerClosed(io.EOF)).
+		False(isPeerClosed(io.ErrUnexpectedEOF))
+}
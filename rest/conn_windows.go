@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+//go:build windows
+
+package rest
+
+import "syscall"
+
+// 连接被对方重置时返回的错误
+var connResetErrnos = []syscall.Errno{syscall.WSAECONNRESET, syscall.WSAECONNABORTED}
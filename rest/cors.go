@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Preflight 生成 CORS 的预检请求
+//
+// 参数说明可参考 [Preflight]。与 [Server.NewRequest] 相同，会应用 [Timeout] 和 [RecordHAR] 等选项。
+func (srv *Server) Preflight(path, origin, method string, headers ...string) *Request {
+	return preflight(srv.NewRequest(http.MethodOptions, path), origin, method, headers)
+}
+
+// Preflight 生成 CORS 的预检请求
+//
+// 即以 OPTIONS 方法请求 path，其中 origin 为 Origin 报头的值，
+// method 为 Access-Control-Request-Method 报头的值，
+// headers 为 Access-Control-Request-Headers 报头的值，为空表示不需要此报头。
+func Preflight(a *assert.Assertion, path, origin, method string, headers ...string) *Request {
+	return preflight(NewRequest(a, http.MethodOptions, path), origin, method, headers)
+}
+
+func preflight(req *Request, origin, method string, headers []string) *Request {
+	req.Header("Origin", origin).
+		Header("Access-Control-Request-Method", method)
+	if len(headers) > 0 {
+		req.Header("Access-Control-Request-Headers", strings.Join(headers, ", "))
+	}
+	return req
+}
+
+// CORSAllowOrigin 断言 Access-Control-Allow-Origin 报头的值为 origin
+//
+// 如果需要断言允许所有的域，origin 可以指定为 *。
+func (resp *Response) CORSAllowOrigin(origin string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := resp.resp.Header.Get("Access-Control-Allow-Origin")
+	return resp.assert(h == origin, assert.NewFailure("CORSAllowOrigin", msg, map[string]interface{}{"v1": h, "v2": origin}))
+}
+
+// CORSAllowMethods 断言 Access-Control-Allow-Methods 报头中包含 methods 中的所有项
+//
+// 如果报头的值为 * 且未允许发送认证信息，则表示允许所有的请求方法。
+func (resp *Response) CORSAllowMethods(methods []string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := headerTokens(resp.resp.Header, "Access-Control-Allow-Methods")
+	return resp.assert(resp.corsContains(h, methods, false), assert.NewFailure("CORSAllowMethods", msg, map[string]interface{}{"v1": h, "v2": methods}))
+}
+
+// CORSAllowHeaders 断言 Access-Control-Allow-Headers 报头中包含 headers 中的所有项
+//
+// 报头名称不区分大小写。如果报头的值为 * 且未允许发送认证信息，则表示允许所有的报头。
+func (resp *Response) CORSAllowHeaders(headers []string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := headerTokens(resp.resp.Header, "Access-Control-Allow-Headers")
+	return resp.assert(resp.corsContains(h, headers, true), assert.NewFailure("CORSAllowHeaders", msg, map[string]interface{}{"v1": h, "v2": headers}))
+}
+
+// CORSExposeHeaders 断言 Access-Control-Expose-Headers 报头中包含 headers 中的所有项
+//
+// 报头名称不区分大小写。如果报头的值为 * 且未允许发送认证信息，则表示允许所有的报头。
+func (resp *Response) CORSExposeHeaders(headers []string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := headerTokens(resp.resp.Header, "Access-Control-Expose-Headers")
+	return resp.assert(resp.corsContains(h, headers, true), assert.NewFailure("CORSExposeHeaders", msg, map[string]interface{}{"v1": h, "v2": headers}))
+}
+
+// CORSAllowCredentials 断言 Access-Control-Allow-Credentials 报头的值为 true
+func (resp *Response) CORSAllowCredentials(msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := resp.resp.Header.Get("Access-Control-Allow-Credentials")
+	return resp.assert(h == "true", assert.NewFailure("CORSAllowCredentials", msg, map[string]interface{}{"v": h}))
+}
+
+// CORSMaxAge 断言 Access-Control-Max-Age 报头的值为 seconds
+func (resp *Response) CORSMaxAge(seconds int, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := resp.resp.Header.Get("Access-Control-Max-Age")
+	return resp.assert(h == strconv.Itoa(seconds), assert.NewFailure("CORSMaxAge", msg, map[string]interface{}{"v1": h, "v2": seconds}))
+}
+
+// NoCORS 断言不存在任何 Access-Control- 开头的报头
+//
+// 可用于判断不被允许的域不会得到 CORS 相关的报头。
+func (resp *Response) NoCORS(msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+
+	var keys []string
+	for k := range resp.resp.Header {
+		if strings.HasPrefix(k, "Access-Control-") {
+			keys = append(keys, k)
+		}
+	}
+
+	return resp.assert(len(keys) == 0, assert.NewFailure("NoCORS", msg, map[string]interface{}{"headers": keys}))
+}
+
+func (resp *Response) corsContains(tokens, vals []string, fold bool) bool {
+	if containsToken(tokens, "*", false) && resp.resp.Header.Get("Access-Control-Allow-Credentials") != "true" {
+		return true
+	}
+
+	for _, v := range vals {
+		if !containsToken(tokens, v, fold) {
+			return false
+		}
+	}
+	return true
+}
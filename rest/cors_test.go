@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+var corsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.Header.Get("Origin") {
+	case "https://allowed.com":
+		w.Header().Set("Access-Control-Allow-Origin", "https://allowed.com")
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total")
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Add("Access-Control-Allow-Methods", "DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Token")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	case "https://public.com":
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestResponse_CORS(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, corsHandler, nil)
+
+	srv.Preflight("/users", "https://allowed.com", http.MethodDelete, "content-type", "x-token").
+		Do(nil).
+		Status(http.StatusNoContent).
+		CORSAllowOrigin("https://allowed.com").
+		CORSAllowMethods([]string{http.MethodGet, http.MethodDelete}).
+		CORSAllowHeaders([]string{"content-type", "X-TOKEN"}).
+		CORSAllowCredentials().
+		CORSMaxAge(600)
+
+	srv.Get("/users").Header("Origin", "https://allowed.com").
+		Do(nil).
+		Status(http.StatusOK).
+		CORSAllowOrigin("https://allowed.com").
+		CORSExposeHeaders([]string{"x-total"}).
+		HeaderContains("Vary", "Origin")
+
+	Preflight(a, "/users", "https://public.com", http.MethodPut, "X-Any").
+		Do(corsHandler).
+		CORSAllowOrigin("*").
+		CORSAllowMethods([]string{http.MethodPut}).
+		CORSAllowHeaders([]string{"X-Any"})
+
+	srv.Preflight("/users", "https://denied.com", http.MethodGet).
+		Do(nil).
+		NoCORS()
+
+	r := Preflight(a, "/users", "https://denied.com", http.MethodGet).Request()
+	a.Equal(r.Method, http.MethodOptions).
+		Equal(r.Header.Get("Origin"), "https://denied.com").
+		Equal(r.Header.Get("Access-Control-Request-Method"), http.MethodGet).
+		Empty(r.Header.Get("Access-Control-Request-Headers"))
+}
+
+func TestResponse_corsContains(t *testing.T) {
+	a := assert.New(t, false)
+
+	resp := &Response{resp: &http.Response{Header: http.Header{}}}
+	a.True(resp.corsContains([]string{"*"}, []string{"GET"}, false)).
+		True(resp.corsContains([]string{"GET", "POST"}, []string{"GET", "POST"}, false)).
+		False(resp.corsContains([]string{"GET"}, []string{"GET", "POST"}, false))
+
+	resp.resp.Header.Set("Access-Control-Allow-Credentials", "true")
+	a.False(resp.corsContains([]string{"*"}, []string{"GET"}, false))
+}
+
+func TestServer_Preflight_timeout(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), nil, Timeout(20*time.Millisecond))
+
+	_, err := srv.Preflight("/", "https://example.com", http.MethodGet).do(nil)
+	a.ErrorIs(err, context.DeadlineExceeded)
+}
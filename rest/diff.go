@@ -0,0 +1,309 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type differ struct {
+	vars  map[string]string
+	diffs []string
+	err   error
+}
+
+type xmlNode struct {
+	name     xml.Name
+	attrs    map[string]string
+	text     string
+	children []*xmlNode
+}
+
+// 根据 Content-Type 按格式比较期望值 exp 与实际值 act
+//
+// 支持 JSON、XML 和 application/x-www-form-urlencoded 格式，
+// 期望值中字符串类型的值可以包含占位符，捕获的变量写入 vars。
+//
+// 返回的 diffs 为所有不相同的部分，为空表示相同；
+// 如果 contentType 不被支持或是内容无法按格式解析，handled 返回 false。
+func diffBody(contentType string, exp, act []byte, vars map[string]string) (diffs []string, handled bool, err error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	d := &differ{vars: vars}
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		e, err1 := decodeJSON(exp)
+		v, err2 := decodeJSON(act)
+		if err1 != nil || err2 != nil {
+			return nil, false, nil
+		}
+		d.json("$", e, v)
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		e, err1 := parseXML(exp)
+		v, err2 := parseXML(act)
+		if err1 != nil || err2 != nil {
+			return nil, false, nil
+		}
+		d.xml("/"+e.name.Local, e, v)
+	case mt == "application/x-www-form-urlencoded":
+		e, err1 := url.ParseQuery(string(exp))
+		v, err2 := url.ParseQuery(string(act))
+		if err1 != nil || err2 != nil {
+			return nil, false, nil
+		}
+		d.form(e, v)
+	default:
+		return nil, false, nil
+	}
+
+	return d.diffs, true, d.err
+}
+
+func (d *differ) add(path, format string, v ...interface{}) {
+	d.diffs = append(d.diffs, path+": "+fmt.Sprintf(format, v...))
+}
+
+// 比较可能包含占位符的值
+func (d *differ) leaf(path, exp, act string) {
+	ok, err := matchPlaceholder(exp, act, d.vars)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	if !ok {
+		d.add(path, "期望值 %s，实际值 %s", exp, act)
+	}
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("包含多余的内容")
+	}
+	return v, nil
+}
+
+func jsonText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func (d *differ) json(path string, exp, act interface{}) {
+	switch e := exp.(type) {
+	case map[string]interface{}:
+		v, ok := act.(map[string]interface{})
+		if !ok {
+			d.add(path, "期望值 %s，实际值 %s", jsonText(exp), jsonText(act))
+			return
+		}
+
+		for _, k := range sortedMapKeys(e) {
+			if vv, found := v[k]; found {
+				d.json(path+"."+k, e[k], vv)
+			} else {
+				d.add(path+"."+k, "缺少字段，期望值 %s", jsonText(e[k]))
+			}
+		}
+		for _, k := range sortedMapKeys(v) {
+			if _, found := e[k]; !found {
+				d.add(path+"."+k, "多余的字段，实际值 %s", jsonText(v[k]))
+			}
+		}
+	case []interface{}:
+		v, ok := act.([]interface{})
+		if !ok {
+			d.add(path, "期望值 %s，实际值 %s", jsonText(exp), jsonText(act))
+			return
+		}
+
+		if len(e) != len(v) {
+			d.add(path, "期望的长度 %d，实际长度 %d", len(e), len(v))
+		}
+		for i := 0; i < len(e) && i < len(v); i++ {
+			d.json(path+"["+strconv.Itoa(i)+"]", e[i], v[i])
+		}
+	case string:
+		// 包含占位符时，非字符串类型的实际值以 JSON 格式进行比较，比如 "{{number}}" 可以匹配 5。
+		if strings.Contains(e, placeholderStart) {
+			v, ok := act.(string)
+			if !ok {
+				v = jsonText(act)
+			}
+			d.leaf(path, e, v)
+			return
+		}
+
+		if v, ok := act.(string); !ok || v != e {
+			d.add(path, "期望值 %s，实际值 %s", jsonText(exp), jsonText(act))
+		}
+	case json.Number:
+		v, ok := act.(json.Number)
+		if !ok || !equalNumber(e, v) {
+			d.add(path, "期望值 %s，实际值 %s", jsonText(exp), jsonText(act))
+		}
+	default: // bool 和 nil
+		if exp != act {
+			d.add(path, "期望值 %s，实际值 %s", jsonText(exp), jsonText(act))
+		}
+	}
+}
+
+func equalNumber(n1, n2 json.Number) bool {
+	if n1 == n2 {
+		return true
+	}
+
+	f1, err1 := n1.Float64()
+	f2, err2 := n2.Float64()
+	return err1 == nil && err2 == nil && f1 == f2
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func parseXML(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				n.attrs[xmlName(attr.Name)] = attr.Value
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			} else {
+				return nil, errors.New("存在多个根元素")
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			n.text = strings.TrimSpace(n.text)
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("缺少根元素")
+	}
+	return root, nil
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+func (d *differ) xml(path string, exp, act *xmlNode) {
+	if exp.name != act.name {
+		d.add(path, "期望的元素 %s，实际元素 %s", xmlName(exp.name), xmlName(act.name))
+		return
+	}
+
+	for _, k := range sortedAttrs(exp.attrs) {
+		if v, found := act.attrs[k]; found {
+			d.leaf(path+"/@"+k, exp.attrs[k], v)
+		} else {
+			d.add(path+"/@"+k, "缺少属性，期望值 %s", exp.attrs[k])
+		}
+	}
+	for _, k := range sortedAttrs(act.attrs) {
+		if _, found := exp.attrs[k]; !found {
+			d.add(path+"/@"+k, "多余的属性，实际值 %s", act.attrs[k])
+		}
+	}
+
+	d.leaf(path, exp.text, act.text)
+
+	if len(exp.children) != len(act.children) {
+		d.add(path, "期望的子元素数量 %d，实际数量 %d", len(exp.children), len(act.children))
+	}
+	for i := 0; i < len(exp.children) && i < len(act.children); i++ {
+		// 子元素按位置比较，两者的名称可能不同，所以路径中以 * 表示。
+		d.xml(path+"/*["+strconv.Itoa(i+1)+"]", exp.children[i], act.children[i])
+	}
+}
+
+func sortedAttrs(attrs map[string]string) []string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (d *differ) form(exp, act url.Values) {
+	for _, k := range sortedKeys(http.Header(exp)) {
+		v, found := act[k]
+		if !found {
+			d.add(k, "缺少参数，期望值 %s", strings.Join(exp[k], ","))
+			continue
+		}
+
+		e := exp[k]
+		if len(e) != len(v) {
+			d.add(k, "期望值 %s，实际值 %s", strings.Join(e, ","), strings.Join(v, ","))
+			continue
+		}
+		for i := range e {
+			d.leaf(k, e[i], v[i])
+		}
+	}
+
+	for _, k := range sortedKeys(http.Header(act)) {
+		if _, found := exp[k]; !found {
+			d.add(k, "多余的参数，实际值 %s", strings.Join(act[k], ","))
+		}
+	}
+}
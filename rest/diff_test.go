@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestDiffBody(t *testing.T) {
+	a := assert.New(t, false)
+
+	data := []struct {
+		ct       string
+		exp, act string
+		handled  bool
+		diffs    []string
+	}{
+		{ct: "text/plain", exp: "abc", act: "abc"},
+		{ct: "", exp: "abc", act: "abc"},
+		{ct: "application/json", exp: "{", act: "{}"}, // 无法解析
+		{ct: "application/json", exp: `{"a":{{any}}}`, act: "{}"},
+
+		// JSON
+		{
+			ct:      "application/json;charset=utf-8",
+			exp:     `{"a":1,"b":[true,null,"s"]}`,
+			act:     "{\n  \"b\": [true, null, \"s\"],\n  \"a\": 1.0\n}",
+			handled: true,
+		},
+		{
+			ct:      "application/problem+json",
+			exp:     `{"a":1,"b":[1,2],"c":"x","d":{"e":false}}`,
+			act:     `{"a":"1","b":[1],"d":{"e":true},"f":null}`,
+			handled: true,
+			diffs: []string{
+				`$.a: 期望值 1，实际值 "1"`,
+				"$.b: 期望的长度 2，实际长度 1",
+				`$.c: 缺少字段，期望值 "x"`,
+				"$.d.e: 期望值 false，实际值 true",
+				"$.f: 多余的字段，实际值 null",
+			},
+		},
+		{
+			ct:      "application/json",
+			exp:     `{"id":"{{uuid}}","n":"{{number}}","list":[{"s":"{{regex ^a}}"}]}`,
+			act:     `{"id":"0b8a1e2c-3f4d-4a5b-8c6d-7e8f9a0b1c2d","n":5,"list":[{"s":"b"}]}`,
+			handled: true,
+			diffs:   []string{"$.list[0].s: 期望值 {{regex ^a}}，实际值 b"},
+		},
+
+		// XML
+		{
+			ct:      "application/xml",
+			exp:     `<root a="1" b="2"><id>7</id><name>n</name></root>`,
+			act:     "<root b=\"2\" a=\"1\">\n  <id>7</id>\n  <name> n </name>\n</root>",
+			handled: true,
+		},
+		{
+			ct:      "text/xml",
+			exp:     `<root a="1"><id>{{number}}</id><name>n</name></root>`,
+			act:     `<root b="2"><id>x</id><title>n</title><x/></root>`,
+			handled: true,
+			diffs: []string{
+				"/root/@a: 缺少属性，期望值 1",
+				"/root/@b: 多余的属性，实际值 2",
+				"/root: 期望的子元素数量 2，实际数量 3",
+				"/root/*[1]: 期望值 {{number}}，实际值 x",
+				"/root/*[2]: 期望的元素 name，实际元素 title",
+			},
+		},
+		{
+			ct:      "application/xml",
+			exp:     `<root><a>1</a><a><b>2</b></a></root>`,
+			act:     `<root><a>1</a><a><b>3</b></a></root>`,
+			handled: true,
+			diffs:   []string{"/root/*[2]/*[1]: 期望值 2，实际值 3"},
+		},
+
+		// form
+		{
+			ct:      "application/x-www-form-urlencoded",
+			exp:     "a=1&b=2&b=3",
+			act:     "b=2&b=3&a=1",
+			handled: true,
+		},
+		{
+			ct:      "application/x-www-form-urlencoded",
+			exp:     "a={{number}}&b=2&c=3",
+			act:     "a=x&b=2&b=3&d=4",
+			handled: true,
+			diffs: []string{
+				"a: 期望值 {{number}}，实际值 x",
+				"b: 期望值 2，实际值 2,3",
+				"c: 缺少参数，期望值 3",
+				"d: 多余的参数，实际值 4",
+			},
+		},
+	}
+
+	for _, item := range data {
+		diffs, handled, err := diffBody(item.ct, []byte(item.exp), []byte(item.act), nil)
+		a.NotError(err, "%s", item.exp).
+			Equal(handled, item.handled, "%s", item.exp).
+			Equal(diffs, item.diffs, "%s", item.exp)
+	}
+
+	vars := map[string]string{}
+	diffs, handled, err := diffBody("application/json", []byte(`{"token":"{{token=any}}"}`), []byte(`{"token":"t"}`), vars)
+	a.NotError(err).True(handled).Empty(diffs).Equal(vars["token"], "t")
+
+	_, handled, err = diffBody("application/json", []byte(`{"token":"{{regex [a-z}}"}`), []byte(`{"token":"t"}`), vars)
+	a.Error(err).True(handled)
+}
+
+func TestRawHandler_diff(t *testing.T) {
+	a := assert.New(t, false)
+	h := BuildHandler(a, http.StatusOK, "{\n  \"name\": \"n\",\n  \"id\": 5\n}", map[string]string{"Content-Type": "application/json"})
+
+	RawHandler(a, h, `GET http://localhost/ HTTP/1.1
+
+`, `HTTP/1.1 200
+Content-Type: application/json
+
+{"id":"{{number}}","name":"n"}
+`)
+}
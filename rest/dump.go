@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/issue9/assert/v4"
+)
+
+const (
+	defaultDumpBodyLimit = 4096
+
+	redacted = "******"
+)
+
+// 输出请求和返回内容时的设置
+type dumper struct {
+	bodyLimit     int      // 报文的最大长度，小于等于 0 表示不限制。
+	redactHeaders []string // 需要隐藏其值的报头
+	redactQuery   []string // 需要隐藏其值的查询参数
+}
+
+func newDumper() dumper {
+	return dumper{
+		bodyLimit:     defaultDumpBodyLimit,
+		redactHeaders: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+	}
+}
+
+// DumpBodyLimit 设置输出请求和返回内容时报文的最大长度
+//
+// 超出部分将被截断，n 小于等于 0 表示不限制，默认值为 4096。
+// 影响 [Response] 断言失败时的输出、[Response.DumpRequest] 等方法以及 [HAR] 的记录。
+func (req *Request) DumpBodyLimit(n int) *Request {
+	req.dumper.bodyLimit = n
+	return req
+}
+
+// RedactHeaders 设置输出请求和返回内容时需要隐藏其值的报头
+//
+// 默认值为 Authorization、Proxy-Authorization、Cookie、Set-Cookie 和 X-Api-Key。
+// 影响 [Response] 断言失败时的输出、[Response.DumpRequest] 等方法以及 [HAR] 的记录。
+func (req *Request) RedactHeaders(keys ...string) *Request {
+	req.dumper.redactHeaders = keys
+	return req
+}
+
+// RedactQuery 设置输出请求内容时需要隐藏其值的查询参数
+//
+// 默认为空，[Request.APIKeyQuery] 指定的参数会自动添加到其中，
+// 所以应该在 [Request.APIKeyQuery] 之前调用。
+// 影响 [Response] 断言失败时的输出、[Response.DumpRequest] 等方法以及 [HAR] 的记录。
+func (req *Request) RedactQuery(keys ...string) *Request {
+	req.dumper.redactQuery = keys
+	return req
+}
+
+// DumpBodyLimit 指定通过当前服务创建的请求的 [Request.DumpBodyLimit]
+func DumpBodyLimit(n int) Option {
+	return func(s *Server) { s.dumper.bodyLimit = n }
+}
+
+// RedactHeaders 指定通过当前服务创建的请求的 [Request.RedactHeaders]
+func RedactHeaders(keys ...string) Option {
+	return func(s *Server) { s.dumper.redactHeaders = keys }
+}
+
+// RedactQuery 指定通过当前服务创建的请求的 [Request.RedactQuery]
+func RedactQuery(keys ...string) Option {
+	return func(s *Server) { s.dumper.redactQuery = keys }
+}
+
+func (d *dumper) isRedacted(key string) bool {
+	for _, k := range d.redactHeaders {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// 查询参数的名称区分大小写
+func (d *dumper) isQueryRedacted(key string) bool {
+	for _, k := range d.redactQuery {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// 返回隐藏了部分查询参数值的地址，参数的顺序保持不变。
+func (d *dumper) url(u *url.URL) string {
+	if len(d.redactQuery) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		key := param
+		if index := strings.IndexByte(param, '='); index >= 0 {
+			key = param[:index]
+		}
+		if k, err := url.QueryUnescape(key); err == nil && d.isQueryRedacted(k) {
+			params[i] = key + "=" + redacted
+		}
+	}
+
+	u2 := *u
+	u2.RawQuery = strings.Join(params, "&")
+	return u2.String()
+}
+
+// 将请求和返回内容附加到 f 中
+func (resp *Response) dump(f *assert.Failure) {
+	if f.Values == nil {
+		f.Values = make(map[string]interface{}, 2)
+	}
+	f.Values["request"] = resp.DumpRequest()
+	f.Values["response"] = resp.DumpResponse()
+}
+
+// DumpRequest 以原始的 HTTP 格式输出请求内容
+//
+// 输出格式与 [RawHTTP] 的 reqRaw 参数兼容，
+// 受 [Request.DumpBodyLimit]、[Request.RedactHeaders] 和 [Request.RedactQuery] 的影响。
+func (resp *Response) DumpRequest() string {
+	r := resp.request
+	if r == nil {
+		return ""
+	}
+
+	b := strings.Builder{}
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(resp.dumper.url(r.URL))
+	b.WriteByte(' ')
+	b.WriteString(r.Proto)
+	b.WriteByte('\n')
+	resp.dumpHeaderBody(&b, r.Header, resp.reqBody)
+	return b.String()
+}
+
+// DumpResponse 以原始的 HTTP 格式输出返回的内容
+//
+// 输出格式与 [RawHTTP] 的 respRaw 参数兼容，受 [Request.DumpBodyLimit] 和 [Request.RedactHeaders] 的影响。
+func (resp *Response) DumpResponse() string {
+	b := strings.Builder{}
+	b.WriteString(resp.resp.Proto)
+	b.WriteByte(' ')
+	b.WriteString(strconv.Itoa(resp.resp.StatusCode))
+	if text := http.StatusText(resp.resp.StatusCode); text != "" {
+		b.WriteByte(' ')
+		b.WriteString(text)
+	}
+	b.WriteByte('\n')
+	resp.dumpHeaderBody(&b, resp.resp.Header, resp.body)
+	return b.String()
+}
+
+// Curl 以 curl 命令的形式输出请求内容
+//
+// 受 [Request.DumpBodyLimit]、[Request.RedactHeaders] 和 [Request.RedactQuery] 的影响。
+func (resp *Response) Curl() string {
+	r := resp.request
+	if r == nil {
+		return ""
+	}
+
+	b := strings.Builder{}
+	b.WriteString("curl -X ")
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(shellQuote(resp.dumper.url(r.URL)))
+
+	for _, k := range sortedKeys(r.Header) {
+		for _, v := range r.Header[k] {
+			if resp.dumper.isRedacted(k) {
+				v = redacted
+			}
+			b.WriteString(" -H ")
+			b.WriteString(shellQuote(k + ": " + v))
+		}
+	}
+
+	if len(resp.reqBody) > 0 {
+		b.WriteString(" --data-binary ")
+		b.WriteString(shellQuote(string(resp.dumper.truncate(resp.reqBody))))
+	}
+
+	return b.String()
+}
+
+func (resp *Response) dumpHeaderBody(b *strings.Builder, header http.Header, body []byte) {
+	body = resp.dumper.truncate(body)
+
+	for _, k := range sortedKeys(header) {
+		if k == "Content-Length" { // 以实际输出的内容为准
+			continue
+		}
+
+		for _, v := range header[k] {
+			if resp.dumper.isRedacted(k) {
+				v = redacted
+			}
+			b.WriteString(k)
+			b.WriteString(": ")
+			b.WriteString(v)
+			b.WriteByte('\n')
+		}
+	}
+	if len(body) > 0 {
+		b.WriteString("Content-Length: ")
+		b.WriteString(strconv.Itoa(len(body)))
+		b.WriteByte('\n')
+	}
+
+	b.WriteByte('\n')
+	b.Write(body)
+}
+
+func (d *dumper) truncate(body []byte) []byte {
+	if d.bodyLimit <= 0 || len(body) <= d.bodyLimit {
+		return body
+	}
+
+	b := make([]byte, 0, d.bodyLimit+50)
+	b = append(b, body[:d.bodyLimit]...)
+	b = append(b, "...(省略 "...)
+	b = strconv.AppendInt(b, int64(len(body)-d.bodyLimit), 10)
+	return append(b, " 字节)"...)
+}
+
+func sortedKeys(h http.Header) []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestResponse_Dump(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil)
+
+	resp := srv.Post("/body", []byte(`{"id":5}`)).
+		Header("Content-Type", "application/json").
+		Header("Authorization", "Bearer token").
+		Do(nil).
+		Status(http.StatusCreated)
+
+	req := resp.DumpRequest()
+	a.Equal(req, "POST "+srv.URL()+`/body HTTP/1.1
+Authorization: ******
+Content-Type: application/json
+Content-Length: 8
+
+{"id":5}`)
+
+	// 可以被 RawHTTP 使用
+	r, err := http.ReadRequest(bufio.NewReader(strings.NewReader(req)))
+	a.NotError(err).NotNil(r)
+	body, err := io.ReadAll(r.Body)
+	a.NotError(err).Equal(string(body), `{"id":5}`)
+
+	a.Equal(resp.DumpResponse(), `HTTP/1.1 201 Created
+Content-Type: application/json;charset=utf-8
+Date: `+resp.Resp().Header.Get("Date")+`
+Content-Length: 8
+
+{"id":6}`)
+
+	a.Equal(resp.Curl(), "curl -X POST '"+srv.URL()+`/body' -H 'Authorization: ******' -H 'Content-Type: application/json' --data-binary '{"id":5}'`)
+
+	f := assert.NewFailure("Test", nil, nil)
+	resp.dump(f)
+	a.Equal(f.Values["request"], req).
+		Equal(f.Values["response"], resp.DumpResponse())
+
+	a.Empty((&Response{}).DumpRequest()).Empty((&Response{}).Curl())
+}
+
+func TestRequest_DumpBodyLimit(t *testing.T) {
+	a := assert.New(t, false)
+
+	resp := Post(a, "/path", []byte("it's body")).
+		Header("X-Secret", "secret").
+		Header("Authorization", "token").
+		DumpBodyLimit(3).
+		RedactHeaders("X-Secret").
+		Do(BuildHandler(a, http.StatusOK, "", nil))
+
+	a.Equal(resp.Curl(), `curl -X POST '/path' -H 'Authorization: token' -H 'X-Secret: ******' --data-binary 'it'\''...(省略 6 字节)'`)
+	a.Equal(resp.DumpRequest(), `POST /path HTTP/1.1
+Authorization: token
+X-Secret: ******
+Content-Length: 23
+
+it'...(省略 6 字节)`)
+
+	d := &dumper{}
+	a.Equal(d.truncate([]byte("it's body")), []byte("it's body"))
+
+	// 通过 Server 的选项指定
+	srv := NewServer(a, BuildHandler(a, http.StatusOK, "", nil), nil, DumpBodyLimit(3), RedactHeaders("X-Secret"))
+	resp = srv.Post("/path", []byte("it's body")).
+		Header("X-Secret", "secret").
+		Header("Authorization", "token").
+		Do(nil)
+	a.Contains(resp.Curl(), `-H 'Authorization: token' -H 'X-Secret: ******' --data-binary 'it'\''...(省略 6 字节)'`)
+
+	// 覆盖 Server 的设置
+	resp = srv.NewRequest(http.MethodGet, "/").Header("Authorization", "token").DumpBodyLimit(0).RedactHeaders().Do(nil)
+	a.Contains(resp.Curl(), `-H 'Authorization: token'`)
+
+	// 默认值
+	resp = Get(a, "/").Header("Authorization", "token").Do(BuildHandler(a, http.StatusOK, "", nil))
+	a.Contains(resp.Curl(), `-H 'Authorization: ******'`)
+}
+
+func TestRequest_RedactQuery(t *testing.T) {
+	a := assert.New(t, false)
+	h := BuildHandler(a, http.StatusOK, "", nil)
+
+	resp := Get(a, "/path").
+		Query("b", "1").
+		Query("token", "t1").
+		Query("a", "2").
+		RedactQuery("token").
+		APIKeyQuery("api_key", "secret").
+		Do(h)
+	a.Equal(resp.Curl(), `curl -X GET '/path?a=2&api_key=******&b=1&token=******'`).
+		True(strings.HasPrefix(resp.DumpRequest(), "GET /path?a=2&api_key=******&b=1&token=****** HTTP/1.1\n"))
+
+	d := &dumper{redactQuery: []string{"k"}}
+	u, err := url.Parse("/p?z=1&k=2&k&a=3")
+	a.NotError(err).Equal(d.url(u), "/p?z=1&k=******&k=******&a=3")
+
+	// 通过 Server 的选项指定，APIKeyQuery 不影响 Server 的设置。
+	srv := NewServer(a, h, nil, RedactQuery("token"))
+	resp = srv.Get("/path").Query("token", "t").APIKeyQuery("api_key", "secret").Do(nil)
+	a.Contains(resp.Curl(), "/path?api_key=******&token=******'")
+	resp = srv.Get("/path").Query("api_key", "k").Do(nil)
+	a.Contains(resp.Curl(), "/path?api_key=k'").
+		Equal(srv.dumper.redactQuery, []string{"token"})
+}
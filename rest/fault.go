@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fault 向处理函数注入故障的中间件
+//
+// 可通过 [Faults] 和 [RandomFaults] 应用到 [Server]，也可以直接用于包装 [http.Handler]。
+type Fault func(http.Handler) http.Handler
+
+// Faults 对符合 when 条件的请求注入故障 f
+//
+// when 为空表示所有请求，f 按顺序由外向内包装处理函数。
+// 故障处于请求记录之内，即被注入故障的请求同样会被 [Server.Records] 记录。
+func Faults(when func(*http.Request) bool, f ...Fault) Option {
+	return func(s *Server) {
+		next := s.server.Config.Handler
+		if next == nil {
+			next = http.DefaultServeMux
+		}
+		faulted := chainFaults(next, f)
+
+		s.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if when == nil || when(r) {
+				faulted.ServeHTTP(w, r)
+			} else {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RandomFaults 以 rate 的概率对请求随机注入 f 中的某一个故障
+//
+// seed 为随机数种子，相同的种子在请求顺序相同的情况下会产生相同的结果；
+// rate 的取值范围为 [0, 1]。
+func RandomFaults(seed int64, rate float64, f ...Fault) Option {
+	if rate < 0 || rate > 1 {
+		panic("rate 的取值范围为 [0, 1]")
+	}
+	if len(f) == 0 {
+		panic("参数 f 不能为空")
+	}
+
+	return func(s *Server) {
+		next := s.server.Config.Handler
+		if next == nil {
+			next = http.DefaultServeMux
+		}
+
+		faulted := make([]http.Handler, 0, len(f))
+		for _, ff := range f {
+			faulted = append(faulted, ff(next))
+		}
+
+		var mu sync.Mutex
+		rnd := rand.New(rand.NewSource(seed))
+
+		s.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hit := rnd.Float64() < rate
+			index := rnd.Intn(len(faulted))
+			mu.Unlock()
+
+			if hit {
+				faulted[index].ServeHTTP(w, r)
+			} else {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func chainFaults(h http.Handler, f []Fault) http.Handler {
+	for i := len(f) - 1; i >= 0; i-- {
+		h = f[i](h)
+	}
+	return h
+}
+
+// Delay 延迟 d 之后再处理请求
+//
+// 如果在延迟期间客户端取消了请求，则不再处理。
+func Delay(d time.Duration) Fault {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := time.NewTimer(d)
+			defer t.Stop()
+
+			select {
+			case <-t.C:
+				next.ServeHTTP(w, r)
+			case <-r.Context().Done():
+			}
+		})
+	}
+}
+
+// ErrorStatus 不再处理请求，直接返回状态码 code
+func ErrorStatus(code int) Fault {
+	return func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, http.StatusText(code), code)
+		})
+	}
+}
+
+// TruncateBody 仅输出内容的前 n 个字节然后断开连接
+//
+// Content-Length 依然为完整内容的长度，客户端读取内容时会返回错误。
+// 内容会先缓存再输出，所以不适用于需要 [http.Flusher] 和 [http.Hijacker] 的处理函数。
+func TruncateBody(n int) Fault {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := bufferResponse(next, w, r)
+			body := rec.Body.Bytes()
+			if len(body) <= n {
+				w.WriteHeader(rec.Code)
+				w.Write(body)
+				return
+			}
+
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(rec.Code)
+			w.Write(body[:n])
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+			panic(http.ErrAbortHandler)
+		})
+	}
+}
+
+// DropConnection 不返回任何内容直接断开连接
+func DropConnection() Fault {
+	return func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if h, ok := w.(http.Hijacker); ok {
+				if conn, _, err := h.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			panic(http.ErrAbortHandler) // HTTP/2 等不支持 Hijack 的情况
+		})
+	}
+}
+
+// SlowBody 将内容按 chunk 字节分块，每隔 interval 输出一块
+//
+// 内容会先缓存再输出，所以不适用于需要 [http.Flusher] 和 [http.Hijacker] 的处理函数。
+func SlowBody(chunk int, interval time.Duration) Fault {
+	if chunk <= 0 {
+		panic("chunk 必须大于 0")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := bufferResponse(next, w, r)
+			body := rec.Body.Bytes()
+
+			if w.Header().Get("Content-Length") == "" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			}
+			w.WriteHeader(rec.Code)
+
+			f, _ := w.(http.Flusher)
+			for len(body) > 0 {
+				size := chunk
+				if size > len(body) {
+					size = len(body)
+				}
+
+				if _, err := w.Write(body[:size]); err != nil {
+					return
+				}
+				if f != nil {
+					f.Flush()
+				}
+				body = body[size:]
+
+				if len(body) > 0 {
+					select {
+					case <-time.After(interval):
+					case <-r.Context().Done():
+						return
+					}
+				}
+			}
+		})
+	}
+}
+
+// 缓存 next 的输出内容，并将报头复制到 w。
+func bufferResponse(next http.Handler, w http.ResponseWriter, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	next.ServeHTTP(rec, r)
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	return rec
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestFaults(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil, Faults(func(r *http.Request) bool {
+		return r.URL.Path == "/get"
+	}, Delay(50*time.Millisecond), ErrorStatus(http.StatusServiceUnavailable)))
+
+	resp := srv.Get("/get").Do(nil).Status(http.StatusServiceUnavailable)
+	a.True(resp.Duration() >= 50*time.Millisecond)
+	srv.Post("/body", []byte(`{"id":5}`)).
+		Header("content-type", "application/json").
+		Do(nil).
+		Status(http.StatusCreated)
+	srv.Received(http.MethodGet, "/get").Received(http.MethodPost, "/body")
+}
+
+func TestTruncateBody(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, BuildHandler(a, http.StatusOK, "123456789", nil), nil, Faults(nil, TruncateBody(3)))
+
+	resp, err := http.Get(srv.URL())
+	a.NotError(err).NotNil(resp).
+		Equal(resp.StatusCode, http.StatusOK).
+		Equal(resp.ContentLength, 9)
+	data, err := io.ReadAll(resp.Body)
+	a.ErrorIs(err, io.ErrUnexpectedEOF).Equal(data, []byte("123"))
+	a.NotError(resp.Body.Close())
+
+	// 内容长度小于 n
+	srv = NewServer(a, BuildHandler(a, http.StatusOK, "12", nil), nil, Faults(nil, TruncateBody(3)))
+	srv.Get("/").Do(nil).Success().StringBody("12")
+}
+
+func TestDropConnection(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil, Faults(nil, DropConnection()))
+
+	resp, err := http.Get(srv.URL() + "/get")
+	a.Error(err).Nil(resp)
+	srv.ReceivedTimes(http.MethodGet, "/get", 1)
+
+	srv = NewTLSServer(a, h, nil, HTTP2(), Faults(nil, DropConnection()))
+	_, err = srv.Get("/get").do(nil)
+	a.Error(err)
+}
+
+func TestSlowBody(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil, Faults(nil, SlowBody(2, 20*time.Millisecond)))
+
+	resp := srv.Post("/body", []byte(`{"id":5}`)).
+		Header("content-type", "application/json").
+		Do(nil).
+		Status(http.StatusCreated).
+		Header("Content-Length", "8").
+		StringBody(`{"id":6}`)
+	a.True(resp.Duration() >= 60*time.Millisecond).
+		True(resp.TTFB() < resp.Duration())
+
+	a.Panic(func() {
+		SlowBody(0, time.Millisecond)
+	})
+}
+
+func TestRandomFaults(t *testing.T) {
+	a := assert.New(t, false)
+
+	codes := func(seed int64, rate float64) []byte {
+		srv := NewServer(a, h, nil, RandomFaults(seed, rate, ErrorStatus(http.StatusInternalServerError), ErrorStatus(http.StatusBadGateway)))
+		buf := &bytes.Buffer{}
+		for i := 0; i < 20; i++ {
+			switch srv.Get("/get").Do(nil).Resp().StatusCode {
+			case http.StatusCreated:
+				buf.WriteByte('.')
+			case http.StatusInternalServerError:
+				buf.WriteByte('5')
+			case http.StatusBadGateway:
+				buf.WriteByte('2')
+			}
+		}
+		return buf.Bytes()
+	}
+
+	c1 := codes(1, 0.5)
+	a.Equal(c1, codes(1, 0.5)).
+		Length(c1, 20).
+		True(bytes.Contains(c1, []byte("."))).
+		True(bytes.Contains(c1, []byte("5"))).
+		True(bytes.Contains(c1, []byte("2")))
+
+	a.Equal(codes(2, 0), bytes.Repeat([]byte("."), 20))
+	a.NotContains(string(codes(2, 1)), ".")
+
+	a.Panic(func() {
+		RandomFaults(1, 1.1, ErrorStatus(http.StatusInternalServerError))
+	})
+	a.Panic(func() {
+		RandomFaults(1, 0.5)
+	})
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.24
+
+package rest
+
+import "net/http"
+
+// H2C 启用未加密的 HTTP/2 支持
+//
+// 仅对 [NewServer] 有效，用于 [NewTLSServer] 时会 panic，加密的 HTTP/2 可使用 [HTTP2]。
+// 服务端同时支持 HTTP/1 和 h2c。
+// 如果未指定 client，会采用以 prior knowledge 方式访问 h2c 的客户端作为默认值。
+//
+// NOTE: 需要 Go 1.24 及之后的版本，之前的版本调用此方法会 panic。
+func H2C() Option {
+	return func(s *Server) {
+		if s.isTLS {
+			panic("H2C 不能用于 NewTLSServer")
+		}
+
+		p := &http.Protocols{}
+		p.SetHTTP1(true)
+		p.SetUnencryptedHTTP2(true)
+		s.server.Config.Protocols = p
+
+		s.newClient = func() *http.Client {
+			p := &http.Protocols{}
+			p.SetUnencryptedHTTP2(true)
+			return &http.Client{Transport: &http.Transport{Protocols: p}}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+//go:build !go1.24
+
+package rest
+
+// H2C 启用未加密的 HTTP/2 支持
+//
+// 标准库在 Go 1.24 之前不支持 h2c，调用此方法会直接 panic。
+func H2C() Option {
+	panic("H2C 需要 Go 1.24 及之后的版本")
+}
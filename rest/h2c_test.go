@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.24
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestH2C(t *testing.T) {
+	a := assert.New(t, false)
+
+	srv := NewServer(a, h, nil, H2C())
+	srv.Get("/get").Do(nil).Status(http.StatusCreated).Proto(2, 0)
+
+	// 普通的客户端依然可以通过 HTTP/1.1 访问
+	srv = NewServer(a, h, &http.Client{}, H2C())
+	srv.Get("/get").Do(nil).Status(http.StatusCreated).Proto(1, 1)
+
+	a.PanicString(func() {
+		NewTLSServer(a, h, nil, H2C())
+	}, "H2C 不能用于 NewTLSServer")
+}
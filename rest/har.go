@@ -0,0 +1,283 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/issue9/assert/v4"
+)
+
+// HAR 以 HTTP Archive 1.2 格式记录请求和返回的内容
+//
+// 生成的文件可以在浏览器的网络面板等工具中查看。
+// 报头和 Cookie 的值同样受 [Request.RedactHeaders] 的影响，查询参数受 [Request.RedactQuery] 的影响，
+// 请求和返回的内容受 [Request.DumpBodyLimit] 的影响。
+type HAR struct {
+	mu      sync.Mutex
+	entries []*harEntry
+}
+
+type (
+	harLog struct {
+		Log struct {
+			Version string      `json:"version"`
+			Creator harCreator  `json:"creator"`
+			Entries []*harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+	}
+
+	harRequest struct {
+		Method      string       `json:"method"`
+		URL         string       `json:"url"`
+		HTTPVersion string       `json:"httpVersion"`
+		Cookies     []harCookie  `json:"cookies"`
+		Headers     []harNV      `json:"headers"`
+		QueryString []harNV      `json:"queryString"`
+		PostData    *harPostData `json:"postData,omitempty"`
+		HeadersSize int          `json:"headersSize"`
+		BodySize    int          `json:"bodySize"`
+	}
+
+	harResponse struct {
+		Status      int         `json:"status"`
+		StatusText  string      `json:"statusText"`
+		HTTPVersion string      `json:"httpVersion"`
+		Cookies     []harCookie `json:"cookies"`
+		Headers     []harNV     `json:"headers"`
+		Content     harContent  `json:"content"`
+		RedirectURL string      `json:"redirectURL"`
+		HeadersSize int         `json:"headersSize"`
+		BodySize    int         `json:"bodySize"`
+	}
+
+	harNV struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harCookie struct {
+		Name     string `json:"name"`
+		Value    string `json:"value"`
+		Path     string `json:"path,omitempty"`
+		Domain   string `json:"domain,omitempty"`
+		Expires  string `json:"expires,omitempty"`
+		HTTPOnly bool   `json:"httpOnly,omitempty"`
+		Secure   bool   `json:"secure,omitempty"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+
+	// 单位均为毫秒，-1 表示不可用。
+	harTimings struct {
+		Blocked float64 `json:"blocked"`
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+		SSL     float64 `json:"ssl"`
+	}
+)
+
+// NewHAR 声明 [HAR] 对象
+//
+// path 为保存的文件路径，如果不为空，会在 testing.TB.Cleanup 中将所有记录写入该文件。
+func NewHAR(a *assert.Assertion, path string) *HAR {
+	h := &HAR{}
+
+	if path != "" {
+		a.TB().Cleanup(func() {
+			f, err := os.Create(path)
+			a.NotError(err).NotNil(f)
+			_, err = h.WriteTo(f)
+			a.NotError(err)
+			a.NotError(f.Close())
+		})
+	}
+
+	return h
+}
+
+// RecordHAR 将通过当前服务创建的请求记录到 h 中
+//
+// 相当于对 [Server.NewRequest] 等方法创建的请求调用 [Request.HAR]。
+func RecordHAR(h *HAR) Option {
+	return func(s *Server) { s.har = h }
+}
+
+// HAR 将当前请求的每一次执行结果记录到 h 中
+//
+// 仅记录通过 [Request.Do] 及其衍生方法执行的请求。
+func (req *Request) HAR(h *HAR) *Request {
+	req.har = h
+	return req
+}
+
+// Len 已经记录的数量
+func (h *HAR) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// WriteTo 将所有记录以 HAR 格式写入 w
+func (h *HAR) WriteTo(w io.Writer) (int64, error) {
+	l := &harLog{}
+	l.Log.Version = "1.2"
+	l.Log.Creator = harCreator{Name: "github.com/issue9/assert/v4/rest", Version: "4"}
+
+	h.mu.Lock()
+	l.Log.Entries = make([]*harEntry, len(h.entries))
+	copy(l.Log.Entries, h.entries)
+	h.mu.Unlock()
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func (h *HAR) add(start time.Time, resp *Response) {
+	r := resp.request
+	e := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            milliseconds(resp.duration),
+		Request: harRequest{
+			Method:      r.Method,
+			URL:         resp.dumper.url(r.URL),
+			HTTPVersion: r.Proto,
+			Cookies:     harCookies(&resp.dumper, r.Cookies(), "Cookie"),
+			Headers:     harHeaders(&resp.dumper, r.Header),
+			QueryString: []harNV{},
+			HeadersSize: -1,
+			BodySize:    len(resp.reqBody),
+		},
+		Response: harResponse{
+			Status:      resp.resp.StatusCode,
+			StatusText:  http.StatusText(resp.resp.StatusCode),
+			HTTPVersion: resp.resp.Proto,
+			Cookies:     harCookies(&resp.dumper, resp.resp.Cookies(), "Set-Cookie"),
+			Headers:     harHeaders(&resp.dumper, resp.resp.Header),
+			Content:     harBody(&resp.dumper, resp.resp.Header.Get("Content-Type"), resp.body),
+			RedirectURL: resp.resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(resp.body),
+		},
+		Timings: harTimings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			Send:    0,
+			Wait:    milliseconds(resp.ttfb),
+			Receive: milliseconds(resp.duration - resp.ttfb),
+			SSL:     -1,
+		},
+	}
+
+	q := r.URL.Query()
+	for _, k := range sortedKeys(http.Header(q)) {
+		for _, v := range q[k] {
+			if resp.dumper.isQueryRedacted(k) {
+				v = redacted
+			}
+			e.Request.QueryString = append(e.Request.QueryString, harNV{Name: k, Value: v})
+		}
+	}
+
+	if len(resp.reqBody) > 0 {
+		e.Request.PostData = &harPostData{MimeType: r.Header.Get("Content-Type"), Text: string(resp.dumper.truncate(resp.reqBody))}
+	}
+
+	h.mu.Lock()
+	h.entries = append(h.entries, e)
+	h.mu.Unlock()
+}
+
+func milliseconds(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+func harHeaders(d *dumper, h http.Header) []harNV {
+	headers := make([]harNV, 0, len(h))
+	for _, k := range sortedKeys(h) {
+		for _, v := range h[k] {
+			if d.isRedacted(k) {
+				v = redacted
+			}
+			headers = append(headers, harNV{Name: k, Value: v})
+		}
+	}
+	return headers
+}
+
+// header 为 cookies 所在的报头名称，用于判断是否需要隐藏其值。
+func harCookies(d *dumper, cookies []*http.Cookie, header string) []harCookie {
+	hide := d.isRedacted(header)
+
+	cs := make([]harCookie, 0, len(cookies))
+	for _, c := range cookies {
+		hc := harCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if hide {
+			hc.Value = redacted
+		}
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.Format(time.RFC3339)
+		}
+		cs = append(cs, hc)
+	}
+	return cs
+}
+
+// 内容会按 [Request.DumpBodyLimit] 截断，但 Size 依然为完整内容的长度。
+func harBody(d *dumper, mimetype string, body []byte) harContent {
+	c := harContent{Size: len(body), MimeType: mimetype}
+	body = d.truncate(body)
+	if utf8.Valid(body) {
+		c.Text = string(body)
+	} else {
+		c.Text = base64.StdEncoding.EncodeToString(body)
+		c.Encoding = "base64"
+	}
+	return c
+}
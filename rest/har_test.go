@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestHAR(t *testing.T) {
+	a := assert.New(t, false)
+	har := NewHAR(a, "")
+	srv := NewServer(a, h, nil, RecordHAR(har))
+
+	srv.Get("/get").Query("k", "v").APIKeyQuery("api_key", "secret").Cookie(&http.Cookie{Name: "c", Value: "secret"}).Do(nil).Status(http.StatusCreated)
+	srv.Post("/body", []byte(`{"id":5}`)).
+		Header("content-type", "application/json").
+		Header("Authorization", "token").
+		Do(nil).
+		Status(http.StatusCreated)
+	a.Equal(har.Len(), 2)
+
+	// 直接调用 Request.HAR
+	NewRequest(a, http.MethodGet, "/get").HAR(har).Do(h).Status(http.StatusCreated)
+	a.Equal(har.Len(), 3)
+
+	buf := &bytes.Buffer{}
+	_, err := har.WriteTo(buf)
+	a.NotError(err)
+
+	l := &harLog{}
+	a.NotError(json.Unmarshal(buf.Bytes(), l))
+	a.Equal(l.Log.Version, "1.2").Length(l.Log.Entries, 3)
+
+	e := l.Log.Entries[0]
+	a.Equal(e.Request.Method, http.MethodGet).
+		Equal(e.Request.QueryString, []harNV{{Name: "api_key", Value: redacted}, {Name: "k", Value: "v"}}).
+		NotContains(e.Request.URL, "secret").
+		Equal(e.Request.Cookies, []harCookie{{Name: "c", Value: redacted}}).
+		Equal(e.Response.Status, http.StatusCreated).
+		Equal(e.Timings.DNS, -1).
+		True(e.Time >= e.Timings.Wait).
+		NotEmpty(e.StartedDateTime)
+
+	e = l.Log.Entries[1]
+	a.Equal(e.Request.PostData, &harPostData{MimeType: "application/json", Text: `{"id":5}`}).
+		Equal(e.Response.Content.Text, `{"id":6}`).
+		Equal(e.Response.Content.MimeType, "application/json;charset=utf-8").
+		Empty(e.Response.Content.Encoding).
+		Contains(e.Request.Headers, []harNV{{Name: "Authorization", Value: redacted}})
+}
+
+func TestNewHAR(t *testing.T) {
+	a := assert.New(t, false)
+	path := filepath.Join(t.TempDir(), "test.har")
+
+	t.Run("write", func(t *testing.T) {
+		a := assert.New(t, false)
+		har := NewHAR(a, path)
+		NewRequest(a, http.MethodGet, "/get").HAR(har).Do(BuildHandler(a, http.StatusOK, "\xff\xfe", nil))
+	})
+
+	data, err := os.ReadFile(path)
+	a.NotError(err)
+	l := &harLog{}
+	a.NotError(json.Unmarshal(data, l))
+	a.Length(l.Log.Entries, 1).
+		Equal(l.Log.Entries[0].Response.Content.Encoding, "base64").
+		Equal(l.Log.Entries[0].Response.Content.Text, "//4=")
+}
+
+func TestHAR_options(t *testing.T) {
+	a := assert.New(t, false)
+	har := NewHAR(a, "")
+	srv := NewServer(a, BuildHandler(a, http.StatusOK, "0123456789", nil), nil, RecordHAR(har), DumpBodyLimit(3))
+
+	srv.Post("/", []byte("0123456789")).Do(nil).Success()
+	srv.Preflight("/", "https://example.com", http.MethodPut).Do(nil).Success()
+	a.Equal(har.Len(), 2)
+
+	buf := &bytes.Buffer{}
+	_, err := har.WriteTo(buf)
+	a.NotError(err)
+	l := &harLog{}
+	a.NotError(json.Unmarshal(buf.Bytes(), l))
+
+	e := l.Log.Entries[0]
+	a.Equal(e.Request.PostData.Text, "012...(省略 7 字节)").
+		Equal(e.Request.BodySize, 10).
+		Equal(e.Response.Content.Text, "012...(省略 7 字节)").
+		Equal(e.Response.Content.Size, 10)
+
+	e = l.Log.Entries[1]
+	a.Equal(e.Request.Method, http.MethodOptions).
+		Contains(e.Request.Headers, []harNV{{Name: "Origin", Value: "https://example.com"}})
+}
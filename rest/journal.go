@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Record [Server] 接收到的请求记录
+type Record struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte    // 请求内容，在请求处理完成之后才会填充。
+	Time   time.Time // 接收到请求的时间
+}
+
+// 处理函数未读取的请求内容，在处理完成之后最多再读取的字节数。
+const recordDrainLimit = 10 << 20
+
+// 将读取的内容同时写入缓存的请求内容
+type teeBody struct {
+	r       io.Reader
+	c       io.Closer
+	drained bool
+}
+
+func (b *teeBody) Read(p []byte) (int, error) { return b.r.Read(p) }
+
+func (b *teeBody) Close() error {
+	b.drain()
+	return b.c.Close()
+}
+
+// 读取处理函数未读取的内容，使其也被记录。
+func (b *teeBody) drain() {
+	if !b.drained {
+		b.drained = true
+		io.CopyN(io.Discard, b.r, recordDrainLimit)
+	}
+}
+
+// 记录所有经过 next 的请求
+func (srv *Server) record(next http.Handler) http.Handler {
+	if next == nil {
+		next = http.DefaultServeMux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &Record{
+			Method: r.Method,
+			URL:    cloneURL(r.URL),
+			Header: r.Header.Clone(),
+			Time:   time.Now(),
+		}
+
+		// 不提前读取内容，以免影响流式处理的处理函数。
+		var buf *bytes.Buffer
+		var body *teeBody
+		if r.Body != nil && r.Body != http.NoBody {
+			buf = &bytes.Buffer{}
+			body = &teeBody{r: io.TeeReader(r.Body, buf), c: r.Body}
+			r.Body = body
+		}
+
+		srv.recordsMu.Lock()
+		srv.records = append(srv.records, rec)
+		srv.recordsMu.Unlock()
+
+		next.ServeHTTP(w, r)
+
+		if body != nil {
+			body.drain()
+		}
+		if buf != nil && buf.Len() > 0 {
+			srv.recordsMu.Lock()
+			rec.Body = buf.Bytes()
+			srv.recordsMu.Unlock()
+		}
+	})
+}
+
+func cloneURL(u *url.URL) *url.URL {
+	u2 := *u
+	if u.User != nil {
+		u2.User = new(url.Userinfo)
+		*u2.User = *u.User
+	}
+	return &u2
+}
+
+func (rec *Record) String() string { return rec.Method + " " + rec.URL.String() }
+
+// 判断是否匹配请求方法 method 和路径 path
+//
+// path 的格式与 [Mock.On] 的 pattern 参数相同。
+func (rec *Record) match(method, path string) bool {
+	return rec.Method == method && patternExpr(path).MatchString(rec.URL.Path)
+}
+
+// Records 返回服务端接收到的所有请求
+//
+// 按接收的顺序排列。
+func (srv *Server) Records() []*Record {
+	srv.recordsMu.Lock()
+	defer srv.recordsMu.Unlock()
+
+	records := make([]*Record, 0, len(srv.records))
+	for _, rec := range srv.records {
+		r := *rec // 请求内容可能在处理完成之后才填充，所以返回副本。
+		records = append(records, &r)
+	}
+	return records
+}
+
+// Last 返回最后接收到的 n 条请求
+//
+// 如果请求数量不足 n，则返回所有的请求。
+func (srv *Server) Last(n int) []*Record {
+	records := srv.Records()
+	if n < len(records) {
+		records = records[len(records)-n:]
+	}
+	return records
+}
+
+// ResetRecords 清空所有的请求记录
+func (srv *Server) ResetRecords() {
+	srv.recordsMu.Lock()
+	srv.records = srv.records[:0]
+	srv.recordsMu.Unlock()
+}
+
+func (srv *Server) count(method, path string) int {
+	var cnt int
+	for _, rec := range srv.Records() {
+		if rec.match(method, path) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// Received 断言服务端接收到过 method 和 path 指定的请求
+//
+// path 的格式与 [Mock.On] 的 pattern 参数相同，可以通过 {} 指定参数。
+func (srv *Server) Received(method, path string, msg ...interface{}) *Server {
+	srv.a.TB().Helper()
+	srv.a.Assert(srv.count(method, path) > 0, assert.NewFailure("Received", msg, map[string]interface{}{"method": method, "path": path, "records": srv.Records()}))
+	return srv
+}
+
+// NotReceived 断言服务端未接收到过 method 和 path 指定的请求
+func (srv *Server) NotReceived(method, path string, msg ...interface{}) *Server {
+	srv.a.TB().Helper()
+	srv.a.Assert(srv.count(method, path) == 0, assert.NewFailure("NotReceived", msg, map[string]interface{}{"method": method, "path": path, "records": srv.Records()}))
+	return srv
+}
+
+// ReceivedTimes 断言服务端接收到 method 和 path 指定的请求的次数为 n
+func (srv *Server) ReceivedTimes(method, path string, n int, msg ...interface{}) *Server {
+	srv.a.TB().Helper()
+	cnt := srv.count(method, path)
+	srv.a.Assert(cnt == n, assert.NewFailure("ReceivedTimes", msg, map[string]interface{}{"method": method, "path": path, "times": cnt, "val": n}))
+	return srv
+}
+
+// ReceivedInOrder 断言服务端按顺序接收到了 calls 指定的请求
+//
+// calls 中的每一项由请求方法和路径组成，以空格分隔，比如 GET /users/{id}。
+// 不要求请求是连续的，中间可以插入其它请求。
+func (srv *Server) ReceivedInOrder(calls []string, msg ...interface{}) *Server {
+	srv.a.TB().Helper()
+
+	records := srv.Records()
+	i := 0
+	for _, rec := range records {
+		if i == len(calls) {
+			break
+		}
+
+		method, path := calls[i], ""
+		if index := strings.IndexByte(method, ' '); index > 0 {
+			method, path = method[:index], strings.TrimSpace(method[index+1:])
+		}
+		if rec.match(method, path) {
+			i++
+		}
+	}
+
+	srv.a.Assert(i == len(calls), assert.NewFailure("ReceivedInOrder", msg, map[string]interface{}{"calls": calls, "records": records}))
+	return srv
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestServer_Records(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil)
+	a.Empty(srv.Records()).Empty(srv.Last(5))
+
+	srv.Get("/get").Query("page", "1").Header("X-Test", "1").Do(nil).Status(http.StatusCreated)
+	srv.Post("/body", []byte(`{"id":5}`)).Header("content-type", "application/json").Do(nil).Status(http.StatusCreated)
+	srv.Delete("/users/5").Do(nil).Status(http.StatusNotFound)
+
+	records := srv.Records()
+	a.Length(records, 3)
+	a.Equal(records[0].Method, http.MethodGet).
+		Equal(records[0].URL.Path, "/get").
+		Equal(records[0].URL.RawQuery, "page=1").
+		Equal(records[0].Header.Get("X-Test"), "1").
+		Empty(records[0].Body).
+		False(records[0].Time.IsZero()).
+		Equal(records[0].String(), "GET /get?page=1")
+	a.Equal(records[1].Body, []byte(`{"id":5}`))
+
+	last := srv.Last(2)
+	a.Length(last, 2).
+		Equal(last[0], records[1]).
+		Equal(last[1], records[2])
+	a.Length(srv.Last(10), 3)
+
+	srv.Received(http.MethodGet, "/get").
+		Received(http.MethodDelete, "/users/{id}").
+		NotReceived(http.MethodGet, "/users/{id}").
+		NotReceived(http.MethodPost, "/get").
+		ReceivedTimes(http.MethodPost, "/body", 1).
+		ReceivedTimes(http.MethodPut, "/body", 0).
+		ReceivedInOrder([]string{"GET /get", "DELETE /users/{id}"}).
+		ReceivedInOrder(nil)
+
+	srv.ResetRecords()
+	a.Empty(srv.Records())
+	srv.NotReceived(http.MethodGet, "/get")
+}
+
+func TestServer_record_streaming(t *testing.T) {
+	a := assert.New(t, false)
+
+	entered := make(chan struct{})
+	srv := NewServer(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered) // 在读取内容之前进入处理函数
+		data, err := io.ReadAll(r.Body)
+		a.NotError(err)
+		w.Write(data)
+	}), nil)
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("123"))
+		select {
+		case <-entered:
+			pw.Write([]byte("456"))
+			pw.Close()
+		case <-time.After(time.Second):
+			pw.CloseWithError(errors.New("未在上传完成之前进入处理函数"))
+		}
+	}()
+
+	resp, err := http.Post(srv.URL(), "text/plain", pr)
+	a.NotError(err).NotNil(resp)
+	data, err := io.ReadAll(resp.Body)
+	a.NotError(err).NotError(resp.Body.Close()).
+		Equal(data, []byte("123456"))
+
+	records := srv.Records()
+	a.Length(records, 1).
+		Equal(records[0].Body, []byte("123456"))
+}
+
+func TestServer_record_unread(t *testing.T) {
+	a := assert.New(t, false)
+
+	// 处理函数未读取内容
+	srv := NewServer(a, BuildHandler(a, http.StatusOK, "", nil), nil)
+	srv.Post("/", []byte("payload")).Do(nil).Success()
+	records := srv.Records()
+	a.Length(records, 1).Equal(records[0].Body, []byte("payload"))
+
+	// 处理函数只读取了部分内容便关闭
+	srv = NewServer(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := r.Body.Read(make([]byte, 2))
+		a.NotError(err).NotError(r.Body.Close())
+	}), nil)
+	srv.Post("/", []byte("payload")).Do(nil).Success()
+	records = srv.Records()
+	a.Length(records, 1).Equal(records[0].Body, []byte("payload"))
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Latency 多次请求的耗时统计
+type Latency struct {
+	a         *assert.Assertion
+	durations []time.Duration // 已排序
+}
+
+// Repeat 重复执行 n 次请求并统计各次的耗时
+//
+// h 的作用与 [Request.Do] 中的相同。
+func (req *Request) Repeat(n int, h http.Handler) *Latency {
+	if n <= 0 {
+		panic("参数 n 必须大于 0")
+	}
+
+	req.a.TB().Helper()
+
+	ds := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		ds = append(ds, req.Do(h).Duration())
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+
+	return &Latency{a: req.a, durations: ds}
+}
+
+// Durations 返回所有请求的耗时
+//
+// 返回值已经从小到大排序。
+func (l *Latency) Durations() []time.Duration {
+	ds := make([]time.Duration, len(l.durations))
+	copy(ds, l.durations)
+	return ds
+}
+
+// Percentile 返回第 p 百分位的耗时
+//
+// p 的取值范围为 (0, 100]，采用最近秩(nearest-rank)方法计算，比如 50 表示中位数，100 表示最大值。
+func (l *Latency) Percentile(p float64) time.Duration {
+	if p <= 0 || p > 100 {
+		panic("参数 p 的取值范围为 (0, 100]")
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(l.durations))))
+	if rank < 1 {
+		rank = 1
+	}
+	return l.durations[rank-1]
+}
+
+// Within 断言第 p 百分位的耗时不超过 d
+func (l *Latency) Within(p float64, d time.Duration, msg ...interface{}) *Latency {
+	l.a.TB().Helper()
+	v := l.Percentile(p)
+	l.a.Assert(v <= d, assert.NewFailure("Within", msg, map[string]interface{}{"percentile": p, "duration": v, "val": d}))
+	return l
+}
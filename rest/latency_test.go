@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestRequest_Repeat(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil)
+
+	l := srv.Post("/body", []byte(`{"id":5}`)).
+		Header("content-type", "application/json").
+		Repeat(5, nil)
+	a.Length(l.Durations(), 5).
+		True(l.Percentile(50) <= l.Percentile(100))
+	l.Within(90, time.Minute)
+
+	a.Panic(func() {
+		srv.Get("/get").Repeat(0, nil)
+	})
+	a.Panic(func() {
+		l.Percentile(0)
+	})
+}
+
+func TestLatency_Percentile(t *testing.T) {
+	a := assert.New(t, false)
+
+	l := &Latency{a: a, durations: []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}}
+	a.Equal(l.Percentile(100), 10).
+		Equal(l.Percentile(50), 5).
+		Equal(l.Percentile(90), 9).
+		Equal(l.Percentile(91), 10).
+		Equal(l.Percentile(0.1), 1)
+
+	a.Panic(func() {
+		l.Percentile(100.1)
+	})
+}
+
+func TestResponse_Duration(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, h, nil)
+
+	resp := srv.Get("/get").Do(nil).
+		Within(time.Minute).
+		TTFBWithin(time.Minute)
+	a.True(resp.TTFB() > 0).
+		True(resp.Duration() >= resp.TTFB())
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+		time.Sleep(20 * time.Millisecond)
+	})
+	resp = Get(a, "/").Do(slow).Status(http.StatusAccepted)
+	a.True(resp.TTFB() >= 20*time.Millisecond).
+		True(resp.Duration() >= 40*time.Millisecond)
+}
@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/issue9/assert/v4"
+)
+
+var paramExpr = regexp.MustCompile(`\{[^/{}]+\}`)
+
+// Mock 根据预设规则返回内容的 [http.Handler] 实现
+//
+// 可用于测试 HTTP 客户端，一般作为 [NewServer] 的参数使用：
+//
+//	m := rest.NewMock(a)
+//	m.On(http.MethodGet, "/users/{id}").Reply(http.StatusOK, `{"id":1}`, nil)
+//	srv := rest.NewServer(a, m, nil)
+//
+// 未匹配任何规则的请求会返回 501 状态码并被记录下来，
+// 在 testing.TB.Cleanup 中会调用 [Mock.Verify] 检测所有的规则和请求。
+type Mock struct {
+	a          *assert.Assertion
+	mu         sync.Mutex
+	routes     []*Route
+	unexpected []string
+}
+
+// Route [Mock] 中的规则
+type Route struct {
+	a        *assert.Assertion
+	method   string
+	pattern  string
+	path     *regexp.Regexp
+	matchers []func(*http.Request, []byte) bool
+	handler  http.Handler
+	times    int
+	calls    int
+}
+
+// NewMock 声明 [Mock] 对象
+func NewMock(a *assert.Assertion) *Mock {
+	m := &Mock{a: a}
+	a.TB().Cleanup(m.Verify)
+	return m
+}
+
+// On 添加一条规则
+//
+// method 表示请求方法；pattern 表示请求的路径，可以通过 {} 指定参数，参数可以匹配除 / 之外的任意字符，
+// 比如 /users/{id} 可以匹配 /users/1。
+//
+// 默认情况下规则必须被调用一次，可以通过 [Route.Times] 修改。
+// 多条规则同时匹配时，按添加顺序选取第一条调用次数未满的规则。
+func (m *Mock) On(method, pattern string) *Route {
+	r := &Route{
+		a:       m.a,
+		method:  method,
+		pattern: pattern,
+		path:    patternExpr(pattern),
+		handler: BuildHandler(m.a, http.StatusOK, "", nil),
+		times:   1,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes = append(m.routes, r)
+
+	return r
+}
+
+// 将 pattern 中的参数转换成正则表达式
+func patternExpr(pattern string) *regexp.Regexp {
+	expr := strings.Builder{}
+	expr.WriteByte('^')
+
+	start := 0
+	for _, loc := range paramExpr.FindAllStringIndex(pattern, -1) {
+		expr.WriteString(regexp.QuoteMeta(pattern[start:loc[0]]))
+		expr.WriteString("[^/]+")
+		start = loc[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[start:]))
+	expr.WriteByte('$')
+
+	return regexp.MustCompile(expr.String())
+}
+
+// Query 要求请求参数 key 中包含值 val
+func (r *Route) Query(key, val string) *Route {
+	return r.Match(func(req *http.Request) bool {
+		for _, v := range req.URL.Query()[key] {
+			if v == val {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Header 要求报头 key 的值为 val
+func (r *Route) Header(key, val string) *Route {
+	return r.Match(func(req *http.Request) bool { return req.Header.Get(key) == val })
+}
+
+// Body 要求提交的内容与 body 相同
+func (r *Route) Body(body []byte) *Route {
+	r.matchers = append(r.matchers, func(_ *http.Request, b []byte) bool { return bytes.Equal(b, body) })
+	return r
+}
+
+// JSONBody 要求提交的内容为与 v 等价的 JSON 数据
+//
+// 比较的是 JSON 的语义，与字段顺序和空白字符无关。
+func (r *Route) JSONBody(v interface{}) *Route {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	var expected interface{}
+	if err := json.Unmarshal(data, &expected); err != nil {
+		panic(err)
+	}
+
+	r.matchers = append(r.matchers, func(_ *http.Request, b []byte) bool {
+		var actual interface{}
+		return json.Unmarshal(b, &actual) == nil && reflect.DeepEqual(actual, expected)
+	})
+	return r
+}
+
+// Match 添加自定义的匹配函数
+//
+// 在 f 中可以读取 [http.Request.Body]，不会影响其它匹配函数和之后的处理。
+func (r *Route) Match(f func(*http.Request) bool) *Route {
+	r.matchers = append(r.matchers, func(req *http.Request, _ []byte) bool { return f(req) })
+	return r
+}
+
+// Times 指定规则必须被调用的次数
+//
+// n 小于 0 表示不限次数。
+func (r *Route) Times(n int) *Route {
+	r.times = n
+	return r
+}
+
+// Reply 指定返回的内容
+//
+// 参数与 [BuildHandler] 相同。
+func (r *Route) Reply(code int, body string, headers map[string]string) *Route {
+	r.handler = BuildHandler(r.a, code, body, headers)
+	return r
+}
+
+// ReplyFunc 指定由 f 生成返回的内容
+func (r *Route) ReplyFunc(f func(http.ResponseWriter, *http.Request)) *Route {
+	r.handler = http.HandlerFunc(f)
+	return r
+}
+
+func (r *Route) match(req *http.Request, body []byte) bool {
+	if r.times >= 0 && r.calls >= r.times {
+		return false
+	}
+
+	if r.method != req.Method || !r.path.MatchString(req.URL.Path) {
+		return false
+	}
+
+	for _, m := range r.matchers {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if !m(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	var route *Route
+	for _, r := range m.routes {
+		if r.match(req, body) {
+			route = r
+			r.calls++
+			break
+		}
+	}
+	if route == nil {
+		m.unexpected = append(m.unexpected, req.Method+" "+req.URL.String())
+	}
+	m.mu.Unlock()
+
+	if route == nil {
+		http.Error(w, "rest.Mock: 没有与请求匹配的规则", http.StatusNotImplemented)
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	route.handler.ServeHTTP(w, req)
+}
+
+// Verify 检测所有规则的调用次数是否符合要求以及是否存在未预期的请求
+//
+// 如果未手动调用，则在 testing.TB.Cleanup 中自动调用。
+func (m *Mock) Verify() {
+	m.a.TB().Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.routes {
+		if r.times >= 0 {
+			m.a.Assert(r.calls == r.times, assert.NewFailure("Verify", nil, map[string]interface{}{"route": r.method + " " + r.pattern, "calls": r.calls, "times": r.times}))
+		}
+	}
+
+	m.a.Assert(len(m.unexpected) == 0, assert.NewFailure("Verify", nil, map[string]interface{}{"unexpected": m.unexpected}))
+}
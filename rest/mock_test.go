@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestPatternExpr(t *testing.T) {
+	a := assert.New(t, false)
+
+	expr := patternExpr("/users/{id}/orders/{oid}.json")
+	a.True(expr.MatchString("/users/1/orders/2.json")).
+		False(expr.MatchString("/users/1/orders/2xjson")).
+		False(expr.MatchString("/users/1/2/orders/2.json")).
+		False(expr.MatchString("/users//orders/2.json"))
+
+	expr = patternExpr("/users")
+	a.True(expr.MatchString("/users")).
+		False(expr.MatchString("/users/1"))
+}
+
+func TestMock(t *testing.T) {
+	a := assert.New(t, false)
+	m := NewMock(a)
+	srv := NewServer(a, m, nil)
+
+	m.On(http.MethodGet, "/users/{id}").
+		Query("fields", "name").
+		Header("Accept", "application/json").
+		Reply(http.StatusOK, `{"id":1}`, map[string]string{"Content-Type": "application/json"}).
+		Times(2)
+	m.On(http.MethodPost, "/users").
+		JSONBody(map[string]interface{}{"name": "n", "age": 5}).
+		Match(func(r *http.Request) bool {
+			data, err := io.ReadAll(r.Body)
+			return err == nil && len(data) > 0
+		}).
+		ReplyFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(data)
+		})
+	m.On(http.MethodDelete, "/users/{id}").
+		Body(nil).
+		Times(-1)
+
+	for i := 0; i < 2; i++ {
+		srv.Get("/users/1").
+			Query("fields", "name").
+			Header("Accept", "application/json").
+			Do(nil).
+			Status(http.StatusOK).
+			StringBody(`{"id":1}`)
+	}
+
+	srv.Post("/users", []byte(`{ "age":5, "name":"n" }`)).
+		Do(nil).
+		Status(http.StatusCreated).
+		StringBody(`{ "age":5, "name":"n" }`)
+
+	srv.Delete("/users/1").Do(nil).Status(http.StatusOK)
+	srv.Delete("/users/2").Do(nil).Status(http.StatusOK)
+}
+
+func TestMock_Verify(t *testing.T) {
+	a := assert.New(t, false)
+
+	// 所有规则都满足
+	tb := &failTB{TB: t}
+	m := NewMock(assert.New(tb, false))
+	m.On(http.MethodGet, "/users/{id}").Times(2)
+	m.On(http.MethodDelete, "/users/{id}").Times(-1)
+	srv := NewServer(assert.New(tb, false), m, nil)
+	srv.Get("/users/1").Do(nil).Status(http.StatusOK)
+	srv.Get("/users/2").Do(nil).Status(http.StatusOK)
+	a.Empty(tb.finish())
+
+	// 调用次数不足
+	tb = &failTB{TB: t}
+	m = NewMock(assert.New(tb, false))
+	m.On(http.MethodGet, "/users/{id}").Times(2)
+	srv = NewServer(assert.New(tb, false), m, nil)
+	srv.Get("/users/1").Do(nil).Status(http.StatusOK)
+	errs := tb.finish()
+	a.Length(errs, 1).
+		Contains(errs[0], "GET /users/{id}").
+		NotContains(errs[0], "unexpected")
+
+	// 调用次数已满以及未定义的请求
+	tb = &failTB{TB: t}
+	m = NewMock(assert.New(tb, false))
+	m.On(http.MethodGet, "/users/{id}")
+	srv = NewServer(assert.New(tb, false), m, nil)
+	srv.Get("/users/1").Do(nil).Status(http.StatusOK)
+	srv.Get("/users/1").Do(nil).Status(http.StatusNotImplemented)
+	srv.Post("/users", nil).Do(nil).Status(http.StatusNotImplemented)
+	errs = tb.finish()
+	a.Length(errs, 1).
+		Contains(errs[0], "GET /users/1").
+		Contains(errs[0], "POST /users")
+}
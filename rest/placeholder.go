@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	placeholderStart = "{{"
+	placeholderEnd   = "}}"
+	placeholderRegex = "regex "
+)
+
+var varName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// 占位符对应的正则表达式
+var placeholders = map[string]string{
+	"any":    `(?s:.*)`,
+	"uuid":   `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"number": `-?[0-9]+(?:\.[0-9]+)?`,
+}
+
+// 将包含占位符的字符串 s 编译为正则表达式
+//
+// 支持以下占位符：
+//   - {{any}} 任意内容，包括空值和换行符；
+//   - {{uuid}} UUID 格式的字符串；
+//   - {{number}} 整数或是小数；
+//   - {{regex expr}} 符合正则表达式 expr 的内容；
+//
+// 以上占位符都可以添加 name= 前缀，比如 {{token=any}}，表示将匹配的内容捕获至变量 name。
+//
+// 无法识别的 {{…}} 会被当作普通字符，比如模板输出的 <p>{{name}}</p>；
+// 占位符之外的内容需要完全匹配。如果 s 中不包含占位符，返回 nil。
+func compilePlaceholder(s string) (*regexp.Regexp, error) {
+	buf := &strings.Builder{}
+	buf.WriteByte('^')
+
+	var found bool
+	for {
+		start := strings.Index(s, placeholderStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(s[start+len(placeholderStart):], placeholderEnd)
+		if end < 0 {
+			break
+		}
+		end += start + len(placeholderStart)
+
+		expr, err := placeholderExpr(strings.TrimSpace(s[start+len(placeholderStart) : end]))
+		if err != nil {
+			return nil, err
+		}
+
+		end += len(placeholderEnd)
+		if expr == "" { // 无法识别的占位符，按普通字符处理。
+			buf.WriteString(regexp.QuoteMeta(s[:end]))
+		} else {
+			buf.WriteString(regexp.QuoteMeta(s[:start]))
+			buf.WriteString(expr)
+			found = true
+		}
+		s = s[end:]
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	buf.WriteString(regexp.QuoteMeta(s))
+	buf.WriteByte('$')
+	return regexp.Compile(buf.String())
+}
+
+// 返回占位符 name 对应的正则表达式，如果无法识别 name，返回空字符串。
+//
+// 仅在 regex 占位符的正则表达式错误时返回 error。
+func placeholderExpr(name string) (string, error) {
+	if index := strings.IndexByte(name, '='); index > 0 && varName.MatchString(name[:index]) {
+		expr, err := placeholderExpr(strings.TrimSpace(name[index+1:]))
+		if err != nil || expr == "" {
+			return "", err
+		}
+		return "(?P<" + name[:index] + ">" + expr + ")", nil
+	}
+
+	if strings.HasPrefix(name, placeholderRegex) {
+		expr := strings.TrimSpace(name[len(placeholderRegex):])
+		if _, err := regexp.Compile(expr); err != nil {
+			return "", err
+		}
+		return "(?:" + expr + ")", nil
+	}
+
+	return placeholders[name], nil
+}
+
+// 比较包含占位符的期望值 exp 与实际值 v
+//
+// 如果 vars 不为 nil，会将捕获的变量写入 vars。
+func matchPlaceholder(exp, v string, vars map[string]string) (bool, error) {
+	expr, err := compilePlaceholder(exp)
+	if err != nil {
+		return false, err
+	}
+
+	if expr == nil {
+		return exp == v, nil
+	}
+
+	matches := expr.FindStringSubmatch(v)
+	if matches == nil {
+		return false, nil
+	}
+
+	if vars != nil {
+		for i, name := range expr.SubexpNames() {
+			if name != "" {
+				vars[name] = matches[i]
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestMatchPlaceholder(t *testing.T) {
+	a := assert.New(t, false)
+
+	data := []struct {
+		exp, v string
+		ok     bool
+		err    bool
+	}{
+		{exp: "abc", v: "abc", ok: true},
+		{exp: "abc", v: "ab", ok: false},
+		{exp: "a.c", v: "abc", ok: false}, // 非占位符部分不是正则
+		{exp: "{{any}}", v: "", ok: true},
+		{exp: "{{any}}", v: "a\nb", ok: true},
+		{exp: "id={{ number }}", v: "id=-1.5", ok: true},
+		{exp: "id={{number}}", v: "id=x", ok: false},
+		{exp: "id={{number}}", v: "id=1x", ok: false},
+		{exp: `{"id":"{{uuid}}"}`, v: `{"id":"0b8a1e2c-3f4d-4a5b-8c6d-7e8f9a0b1c2d"}`, ok: true},
+		{exp: `{"id":"{{uuid}}"}`, v: `{"id":"0b8a1e2c"}`, ok: false},
+		{exp: "{{regex [a-z]+}}-{{number}}", v: "abc-5", ok: true},
+		{exp: "{{regex [a-z]+}}-{{number}}", v: "ABC-5", ok: false},
+		{exp: "{{not-exists}}", v: "{{not-exists}}", ok: true}, // 无法识别的占位符按普通字符处理
+		{exp: "{{not-exists}}", v: "x", ok: false},
+		{exp: "<p>{{name}}</p>{{number}}", v: "<p>{{name}}</p>5", ok: true},
+		{exp: "<p>{{name}}</p>{{number}}", v: "<p>x</p>5", ok: false},
+		{exp: "{{v=name}}", v: "{{v=name}}", ok: true},
+		{exp: "{{regex [a-z}}", err: true},
+		{exp: "{{any", v: "{{any", ok: true}, // 未闭合的占位符按普通字符处理
+	}
+
+	for _, item := range data {
+		ok, err := matchPlaceholder(item.exp, item.v, nil)
+		if item.err {
+			a.Error(err, "%s", item.exp)
+			continue
+		}
+		a.NotError(err, "%s", item.exp).Equal(ok, item.ok, "%s:%s", item.exp, item.v)
+	}
+}
+
+func TestRawHandler_placeholder(t *testing.T) {
+	a := assert.New(t, false)
+	h := BuildHandler(a, http.StatusCreated, `{"id":"0b8a1e2c-3f4d-4a5b-8c6d-7e8f9a0b1c2d","created":1700000000}`, map[string]string{
+		"Date":         "Mon, 02 Jan 2006 15:04:05 GMT",
+		"X-Request-Id": "42",
+	})
+
+	RawHandler(a, h, `GET http://localhost/ HTTP/1.1
+
+`, `HTTP/1.1 201 Created
+Date: {{any}}
+X-Request-Id: {{number}}
+
+{"id":"{{uuid}}","created":{{regex \d+}}}
+`)
+}
+
+func TestRawHandler_literalBraces(t *testing.T) {
+	a := assert.New(t, false)
+	h := BuildHandler(a, http.StatusOK, "<p>{{name}}</p>", map[string]string{"X-Template": "{{.Name}}"})
+
+	RawHandler(a, h, `GET http://localhost/ HTTP/1.1
+
+`, `HTTP/1.1 200
+X-Template: {{.Name}}
+
+<p>{{name}}</p>
+`)
+}
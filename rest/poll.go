@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Poll 重复发送请求直到 f 返回 true
+//
+// 每隔 interval 发送一次请求，每次都会重新构建请求内容，直到 f 返回 true 或是超过 timeout。
+// 每次请求的超时时间为剩余的时间，如果 [Request.Timeout] 指定的值更小，则采用该值。
+// 请求出错（比如服务尚未启动或是请求超时）时不会断言失败，而是继续重试。
+// 超时则断言失败，失败信息中包含最后一次的错误信息、请求和返回内容以及请求的次数，
+// 如果从未得到过返回内容，则在断言失败之后调用 testing.TB.FailNow。
+//
+// h 的作用与 [Request.Do] 中的相同；f 的参数分别为返回的 [http.Response] 和其报文内容。
+// 返回值为最后一次请求的 [Response]，可以继续对其进行断言：
+//
+//	srv.Get("/jobs/1").
+//	    Poll(nil, 100*time.Millisecond, 5*time.Second, func(resp *http.Response, body []byte) bool {
+//	        return bytes.Contains(body, []byte(`"done"`))
+//	    }).
+//	    Status(http.StatusOK)
+func (req *Request) Poll(h http.Handler, interval, timeout time.Duration, f func(*http.Response, []byte) bool, msg ...interface{}) *Response {
+	if req.client == nil && h == nil {
+		panic("h 不能为空")
+	}
+
+	req.a.TB().Helper()
+
+	parent := req.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	var last *Response
+	for attempts := 1; ; attempts++ {
+		attempt := *req
+		attempt.ctx = ctx
+		resp, err := attempt.do(h) // 服务可能尚未就绪，出错时视为未达到预期状态。
+		if err == nil && f(resp.resp, resp.body) {
+			return resp
+		}
+		if resp != nil {
+			last = resp
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			fail := assert.NewFailure("Poll", msg, map[string]interface{}{"attempts": attempts, "timeout": timeout, "err": err})
+			if last == nil {
+				req.a.Assert(false, fail)
+				req.a.TB().FailNow()
+			}
+			return last.assert(false, fail)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestRequest_Poll(t *testing.T) {
+	a := assert.New(t, false)
+
+	var mu sync.Mutex
+	var bodies []string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		cnt := len(bodies)
+		mu.Unlock()
+
+		if cnt < 3 {
+			w.Write([]byte(`{"status":"running"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"done"}`))
+	})
+	srv := NewServer(a, h, nil)
+
+	srv.Post("/jobs/1", []byte("query")).
+		Poll(nil, 10*time.Millisecond, time.Second, func(resp *http.Response, body []byte) bool {
+			return resp.StatusCode == http.StatusOK && bytes.Contains(body, []byte(`"done"`))
+		}).
+		Status(http.StatusOK).
+		StringBody(`{"status":"done"}`)
+
+	// 每次请求都会重新发送内容
+	a.Equal(bodies, []string{"query", "query", "query"})
+}
+
+func TestRequest_Poll_error(t *testing.T) {
+	a := assert.New(t, false)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NotError(err)
+	addr := l.Addr().String()
+	a.NotError(l.Close())
+
+	// 始终无法连接，之后的断言不会执行。
+	tb := &failTB{TB: t}
+	completed := tb.run(func() {
+		NewRequest(assert.New(tb, false), http.MethodGet, "http://"+addr).
+			Client(&http.Client{}).
+			Poll(nil, 10*time.Millisecond, 50*time.Millisecond, func(*http.Response, []byte) bool { return true }).
+			Status(http.StatusOK)
+	})
+	a.False(completed)
+	errs := tb.finish()
+	a.Length(errs, 1).
+		Contains(errs[0], "attempts").
+		Contains(errs[0], "connect")
+
+	// 服务在一段时间之后才启动
+	srv := httptest.NewUnstartedServer(BuildHandler(a, http.StatusOK, "ok", nil))
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		time.Sleep(50 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		srv.Listener.Close()
+		srv.Listener = l
+		srv.Start()
+	}()
+	t.Cleanup(func() {
+		<-started
+		srv.Close()
+	})
+
+	NewRequest(a, http.MethodGet, "http://"+addr).
+		Client(&http.Client{}).
+		Poll(nil, 10*time.Millisecond, 5*time.Second, func(resp *http.Response, _ []byte) bool {
+			return resp.StatusCode == http.StatusOK
+		}).
+		StringBody("ok")
+}
+
+func TestRequest_Poll_timeout(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}), nil)
+
+	tb := &failTB{TB: t}
+	start := time.Now()
+	completed := tb.run(func() {
+		NewRequest(assert.New(tb, false), http.MethodGet, srv.URL()).
+			Client(srv.client).
+			Poll(nil, 10*time.Millisecond, 50*time.Millisecond, func(*http.Response, []byte) bool { return true }).
+			Status(http.StatusOK)
+	})
+	a.False(completed).
+		True(time.Since(start) < time.Second)
+	errs := tb.finish()
+	a.Length(errs, 1).
+		Contains(errs[0], "deadline exceeded")
+
+	// Request.Timeout 更小时，以其为准。
+	tb = &failTB{TB: t}
+	completed = tb.run(func() {
+		NewRequest(assert.New(tb, false), http.MethodGet, srv.URL()).
+			Client(srv.client).
+			Timeout(10*time.Millisecond).
+			Poll(nil, 10*time.Millisecond, 100*time.Millisecond, func(*http.Response, []byte) bool { return true })
+	})
+	a.False(completed)
+	errs = tb.finish()
+	a.Length(errs, 1).
+		NotContains(errs[0], "attempts=1\n") // 每次请求都在 10ms 时超时，有多次重试。
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/url"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Redirects 返回重定向经过的所有地址
+//
+// 按重定向的顺序排列，不包含最初请求的地址。未跟随重定向时返回空值。
+func (resp *Response) Redirects() []string {
+	r := make([]string, len(resp.redirects))
+	copy(r, resp.redirects)
+	return r
+}
+
+// 返回重定向的目标地址
+//
+// 如果是未跟随的重定向，返回 Location 报头指向的地址，否则返回最后一次重定向的地址。
+func (resp *Response) redirectTarget() string {
+	if code := resp.resp.StatusCode; code >= 300 && code < 400 {
+		loc := resp.resp.Header.Get("Location")
+		if loc == "" {
+			return ""
+		}
+
+		u, err := url.Parse(loc)
+		if err != nil {
+			return loc
+		}
+		// 以最后一次请求的地址为基准，跟随过重定向时与最初请求的地址不同。
+		if r := resp.resp.Request; r != nil && r.URL != nil {
+			u = r.URL.ResolveReference(u)
+		} else if resp.request != nil {
+			u = resp.request.URL.ResolveReference(u)
+		}
+		return u.String()
+	}
+
+	if l := len(resp.redirects); l > 0 {
+		return resp.redirects[l-1]
+	}
+	return ""
+}
+
+// RedirectsTo 断言请求被重定向到 url
+//
+// 如果指定了 [Request.NoRedirect]，判断的是 Location 报头指向的地址，否则为最后一次重定向的地址。
+// url 如果不包含协议和域名部分，则只比较路径和查询参数。
+func (resp *Response) RedirectsTo(url string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	target := resp.redirectTarget()
+	return resp.assert(target != "" && sameURL(target, url), assert.NewFailure("RedirectsTo", msg, map[string]interface{}{"v1": target, "v2": url}))
+}
+
+// RedirectChain 断言重定向依次经过了 urls 中的地址
+//
+// urls 中的每一项与 [Response.RedirectsTo] 的 url 参数相同，不包含最初请求的地址。
+func (resp *Response) RedirectChain(urls []string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+
+	eq := len(urls) == len(resp.redirects)
+	if eq {
+		for i, u := range urls {
+			if !sameURL(resp.redirects[i], u) {
+				eq = false
+				break
+			}
+		}
+	}
+
+	return resp.assert(eq, assert.NewFailure("RedirectChain", msg, map[string]interface{}{"v1": resp.redirects, "v2": urls}))
+}
+
+func sameURL(actual, expected string) bool {
+	if actual == expected {
+		return true
+	}
+
+	e, err := url.Parse(expected)
+	if err != nil || e.IsAbs() || e.Host != "" {
+		return false
+	}
+
+	a, err := url.Parse(actual)
+	if err != nil {
+		return false
+	}
+	return a.RequestURI() == e.RequestURI()
+}
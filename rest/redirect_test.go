@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+var redirectHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/r1":
+		http.Redirect(w, r, "/r2?page=1", http.StatusFound)
+	case "/r2":
+		http.Redirect(w, r, "/final", http.StatusMovedPermanently)
+	case "/a":
+		http.Redirect(w, r, "/dir/b", http.StatusFound)
+	case "/dir/b":
+		w.Header().Set("Location", "c") // 相对于 /dir/b 的地址
+		w.WriteHeader(http.StatusFound)
+	case "/loop":
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+})
+
+func TestResponse_Redirect(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, redirectHandler, nil)
+
+	resp := srv.Get("/r1").Do(nil).
+		Status(http.StatusOK).
+		RedirectsTo("/final").
+		RedirectsTo(srv.URL() + "/final").
+		RedirectChain([]string{"/r2?page=1", srv.URL() + "/final"})
+	a.Equal(resp.Redirects(), []string{srv.URL() + "/r2?page=1", srv.URL() + "/final"})
+
+	resp = srv.Get("/r1").NoRedirect().Do(nil).
+		Status(http.StatusFound).
+		RedirectsTo("/r2?page=1").
+		RedirectChain(nil)
+	a.Empty(resp.Redirects())
+
+	srv.Get("/final").Do(nil).Status(http.StatusOK).RedirectChain([]string{})
+
+	// 通过 http.Handler 执行时不会跟随重定向
+	Get(a, "/r2").Do(redirectHandler).
+		Status(http.StatusMovedPermanently).
+		RedirectsTo("/final")
+
+	// 采用客户端的 CheckRedirect
+	client := &http.Client{CheckRedirect: func(r *http.Request, via []*http.Request) error {
+		if len(via) >= 2 {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}}
+	NewRequest(a, http.MethodGet, srv.URL()+"/loop").Client(client).Do(nil).
+		Status(http.StatusFound).
+		RedirectChain([]string{"/loop"}).
+		RedirectsTo("/loop")
+}
+
+func TestSameURL(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.True(sameURL("http://example.com/p?q=1", "http://example.com/p?q=1")).
+		True(sameURL("http://example.com/p?q=1", "/p?q=1")).
+		False(sameURL("http://example.com/p?q=1", "/p")).
+		False(sameURL("http://example.com/p", "https://example.com/p")).
+		False(sameURL("http://example.com/p", "//example.org/p"))
+}
+
+func TestResponse_redirectTarget(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, redirectHandler, nil)
+
+	// 跟随一次重定向之后停止，相对地址以最后一次请求的地址为基准。
+	client := &http.Client{CheckRedirect: func(r *http.Request, via []*http.Request) error {
+		if len(via) >= 2 {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}}
+	NewRequest(a, http.MethodGet, srv.URL()+"/a").Client(client).Do(nil).
+		Status(http.StatusFound).
+		RedirectChain([]string{"/dir/b"}).
+		RedirectsTo(srv.URL() + "/dir/c")
+
+	srv.Get("/dir/b").NoRedirect().Do(nil).RedirectsTo("/dir/c")
+	Get(a, "/dir/b").Do(redirectHandler).RedirectsTo("/dir/c")
+}
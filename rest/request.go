@@ -6,10 +6,12 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/issue9/assert/v4"
 )
@@ -18,13 +20,20 @@ import (
 type Request struct {
 	path    string
 	method  string
-	body    io.Reader
+	body    []byte
 	queries url.Values
 	cookies []*http.Cookie
 	params  map[string]string
 	headers map[string]string
 	a       *assert.Assertion
 	client  *http.Client
+
+	noRedirect bool
+	signers    []func(*http.Request, []byte) error
+	ctx        context.Context
+	timeout    time.Duration
+	har        *HAR
+	dumper     dumper
 }
 
 // NewRequest 获取一条请求的结果
@@ -39,7 +48,10 @@ type Request struct {
 //	resp1 := r.Param("id", "1").Do()
 //	resp2 := r.Param("id", "2").Do()
 func (srv *Server) NewRequest(method, path string) *Request {
-	return NewRequest(srv.a, method, srv.URL()+path).Client(srv.client)
+	return NewRequest(srv.a, method, srv.URL()+path).Client(srv.client).Timeout(srv.timeout).HAR(srv.har).
+		DumpBodyLimit(srv.dumper.bodyLimit).
+		RedactHeaders(srv.dumper.redactHeaders...).
+		RedactQuery(srv.dumper.redactQuery...)
 }
 
 func (srv *Server) Get(path string) *Request {
@@ -68,6 +80,7 @@ func NewRequest(a *assert.Assertion, method, path string) *Request {
 		a:      a,
 		method: method,
 		path:   path,
+		dumper: newDumper(),
 	}
 }
 
@@ -99,6 +112,32 @@ func (req *Request) Client(c *http.Client) *Request {
 	return req
 }
 
+// Context 指定请求的上下文
+//
+// 可用于取消请求或是指定请求的截止时间。
+func (req *Request) Context(ctx context.Context) *Request {
+	req.ctx = ctx
+	return req
+}
+
+// Timeout 指定 [Request.Do] 的超时时间
+//
+// 包括发送请求和读取返回内容的时间，0 表示不限制。
+// 通过 [Server] 创建的请求，默认值为 [Timeout] 选项指定的值。
+func (req *Request) Timeout(d time.Duration) *Request {
+	req.timeout = d
+	return req
+}
+
+// NoRedirect 不跟随重定向
+//
+// 默认情况下会按 [http.Client.CheckRedirect] 的规则跟随重定向，
+// 指定此值之后，将直接返回重定向的响应，可以通过 [Response.RedirectsTo] 判断其目标地址。
+func (req *Request) NoRedirect() *Request {
+	req.noRedirect = true
+	return req
+}
+
 // Query 添加一个请求参数
 func (req *Request) Query(key, val string) *Request {
 	if req.queries == nil {
@@ -139,15 +178,14 @@ func (req *Request) Param(key, val string) *Request {
 }
 
 // Body 指定提交的内容
+//
+// 内容会被保存下来，每次生成 [http.Request] 时都会重新构建读取对象，所以同一个 [Request] 可以多次发送。
 func (req *Request) Body(body []byte) *Request {
-	req.body = bytes.NewReader(body)
+	req.body = body
 	return req
 }
 
-func (req *Request) StringBody(body string) *Request {
-	req.body = bytes.NewBufferString(body)
-	return req
-}
+func (req *Request) StringBody(body string) *Request { return req.Body([]byte(body)) }
 
 // BodyFunc 指定一个未编码的对象
 //
@@ -179,8 +217,27 @@ func (req *Request) buildPath() string {
 func (req *Request) Request() *http.Request {
 	req.a.TB().Helper()
 
-	r, err := http.NewRequest(req.method, req.buildPath(), req.body)
+	r, err := req.request()
 	req.a.NotError(err).NotNil(r)
+	return r
+}
+
+// 与 Request 相同，但是返回错误而不是直接断言，可以在非测试的 goroutine 中调用。
+func (req *Request) request() (*http.Request, error) {
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+
+	ctx := req.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r, err := http.NewRequestWithContext(ctx, req.method, req.buildPath(), body)
+	if err != nil {
+		return nil, err
+	}
 	r.Close = true
 
 	for k, v := range req.headers {
@@ -191,5 +248,11 @@ func (req *Request) Request() *http.Request {
 		r.AddCookie(c)
 	}
 
-	return r
+	for _, sign := range req.signers {
+		if err := sign(r, req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
 }
@@ -6,23 +6,45 @@ package rest
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/issue9/assert/v4"
 )
 
 // Response 测试请求的返回结构
 type Response struct {
-	resp *http.Response
-	a    *assert.Assertion
-	body []byte
+	resp     *http.Response
+	a        *assert.Assertion
+	body     []byte
+	ttfb     time.Duration
+	duration time.Duration
+
+	request   *http.Request // 发送的请求
+	reqBody   []byte
+	redirects []string // 重定向经过的地址
+	dumper    dumper
+}
+
+// 记录第一次写入内容的时间
+type recorder struct {
+	*httptest.ResponseRecorder
+	first time.Time
 }
 
 // Do 执行请求操作
 //
 // h 默认为空，如果不为空，则表示当前请求忽略 [http.Client]，而是访问 h.ServeHTTP 的内容。
+//
+// 同时会记录请求的耗时，可以通过 [Response.Duration] 和 [Response.TTFB] 获取。
 func (req *Request) Do(h http.Handler) *Response {
 	if req.client == nil && h == nil {
 		panic("h 不能为空")
@@ -30,32 +52,120 @@ func (req *Request) Do(h http.Handler) *Response {
 
 	req.a.TB().Helper()
 
-	r := req.Request()
-	var err error
+	resp, err := req.do(h)
+	req.a.NotError(err).NotNil(resp)
+	return resp
+}
+
+// 执行请求操作
+//
+// 不会对错误进行断言，可以在非测试的 goroutine 中调用。
+// 如果在读取内容时出错，会同时返回已经读取的 [Response] 和错误信息。
+func (req *Request) do(h http.Handler) (*Response, error) {
+	r, err := req.request()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), req.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
 	var resp *http.Response
+	var first time.Time
+	var redirects []string
+	start := time.Now()
 	if h != nil {
-		w := httptest.NewRecorder()
+		w := &recorder{ResponseRecorder: httptest.NewRecorder()}
 		h.ServeHTTP(w, r)
 		resp = w.Result()
+		first = w.first
 	} else {
-		resp, err = req.client.Do(r)
-		req.a.NotError(err).NotNil(resp)
+		trace := &httptrace.ClientTrace{
+			GotFirstResponseByte: func() {
+				if first.IsZero() { // 存在重定向时，以第一次为准。
+					first = time.Now()
+				}
+			},
+		}
+		r = r.WithContext(httptrace.WithClientTrace(r.Context(), trace))
+
+		client := *req.client
+		client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+			if req.noRedirect {
+				return http.ErrUseLastResponse
+			}
+
+			if req.client.CheckRedirect != nil {
+				if err := req.client.CheckRedirect(next, via); err != nil {
+					return err
+				}
+			} else if len(via) >= 10 { // 与 http.Client 的默认行为相同
+				return errors.New("stopped after 10 redirects")
+			}
+
+			redirects = append(redirects, next.URL.String())
+			return nil
+		}
+		if resp, err = client.Do(r); err != nil {
+			return nil, err
+		}
 	}
 
 	var bs []byte
 	if resp.Body != nil {
-		bs, err = io.ReadAll(resp.Body)
-		if err != io.EOF {
-			req.a.NotError(err)
+		if bs, err = io.ReadAll(resp.Body); err == nil {
+			err = resp.Body.Close()
+		} else {
+			resp.Body.Close()
 		}
-		req.a.NotError(resp.Body.Close())
 	}
 
-	return &Response{
-		a:    req.a,
-		resp: resp,
-		body: bs,
+	end := time.Now()
+	if first.IsZero() {
+		first = end
+	}
+
+	ret := &Response{
+		a:         req.a,
+		resp:      resp,
+		body:      bs,
+		ttfb:      first.Sub(start),
+		duration:  end.Sub(start),
+		request:   r,
+		reqBody:   req.body,
+		redirects: redirects,
+		dumper:    req.dumper,
+	}
+
+	if req.har != nil {
+		req.har.add(start, ret)
 	}
+
+	return ret, err
+}
+
+func (w *recorder) mark() {
+	if w.first.IsZero() {
+		w.first = time.Now()
+	}
+}
+
+func (w *recorder) WriteHeader(code int) {
+	w.mark()
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.mark()
+	return w.ResponseRecorder.Write(b)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	w.mark()
+	return w.ResponseRecorder.WriteString(s)
 }
 
 // Resp 返回 [http.Response] 实例
@@ -63,8 +173,32 @@ func (req *Request) Do(h http.Handler) *Response {
 // NOTE: [http.Response.Body] 内容已经被读取且关闭。
 func (resp *Response) Resp() *http.Response { return resp.resp }
 
+// Duration 从发送请求到读取完所有返回内容的总耗时
+func (resp *Response) Duration() time.Duration { return resp.duration }
+
+// TTFB 从发送请求到接收到第一个字节的耗时
+//
+// 如果是通过 [http.Handler] 执行的请求，则为第一次写入内容的时间。
+func (resp *Response) TTFB() time.Duration { return resp.ttfb }
+
+// Within 断言请求的总耗时不超过 d
+func (resp *Response) Within(d time.Duration, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(resp.duration <= d, assert.NewFailure("Within", msg, map[string]interface{}{"duration": resp.duration, "val": d}))
+}
+
+// TTFBWithin 断言接收到第一个字节的耗时不超过 d
+func (resp *Response) TTFBWithin(d time.Duration, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(resp.ttfb <= d, assert.NewFailure("TTFBWithin", msg, map[string]interface{}{"ttfb": resp.ttfb, "val": d}))
+}
+
+// 断言失败时会在 f 中附加完整的请求和返回内容
 func (resp *Response) assert(expr bool, f *assert.Failure) *Response {
 	resp.a.TB().Helper()
+	if !expr {
+		resp.dump(f)
+	}
 	resp.a.Assert(expr, f)
 	return resp
 }
@@ -97,6 +231,13 @@ func (resp *Response) NotStatus(status int, msg ...interface{}) *Response {
 	return resp.assert(neq, assert.NewFailure("NotStatus", msg, map[string]interface{}{"status": resp.resp.StatusCode}))
 }
 
+// Proto 判断返回内容的协议版本是否为 major.minor
+func (resp *Response) Proto(major, minor int, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	eq := resp.resp.ProtoMajor == major && resp.resp.ProtoMinor == minor
+	return resp.assert(eq, assert.NewFailure("Proto", msg, map[string]interface{}{"proto": resp.resp.Proto, "major": major, "minor": minor}))
+}
+
 // Header 判断指定的报头是否与 val 相同
 //
 // msg 可以为空，会返回一个默认的错误提示信息
@@ -113,6 +254,114 @@ func (resp *Response) NotHeader(key string, val string, msg ...interface{}) *Res
 	return resp.assert(h != val, assert.NewFailure("NotHeader", msg, map[string]interface{}{"header": key, "v": h}))
 }
 
+// HeaderValues 判断报头 key 的所有值是否与 vals 相同
+//
+// 与 [Response.Header] 仅比较第一个值不同，此方法会比较 [http.Header.Values] 返回的所有值，且顺序也需要相同。
+func (resp *Response) HeaderValues(key string, vals []string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := resp.resp.Header.Values(key)
+
+	eq := len(h) == len(vals)
+	if eq {
+		for i, v := range h {
+			if v != vals[i] {
+				eq = false
+				break
+			}
+		}
+	}
+
+	return resp.assert(eq, assert.NewFailure("HeaderValues", msg, map[string]interface{}{"header": key, "v1": h, "v2": vals}))
+}
+
+// HeaderContains 判断报头 key 中是否包含 val
+//
+// 报头的值会被当作以逗号分隔的列表处理，比如 Vary: Accept, Origin，
+// 无论是否写在同一行中，只要其中一项与 val 相同即可。
+func (resp *Response) HeaderContains(key, val string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := headerTokens(resp.resp.Header, key)
+	return resp.assert(containsToken(h, val, false), assert.NewFailure("HeaderContains", msg, map[string]interface{}{"header": key, "v": h, "val": val}))
+}
+
+// HeaderExists 判断报头 key 是否存在
+//
+// 值为空的报头也被当作存在。
+func (resp *Response) HeaderExists(key string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	_, found := resp.resp.Header[http.CanonicalHeaderKey(key)]
+	return resp.assert(found, assert.NewFailure("HeaderExists", msg, map[string]interface{}{"header": key}))
+}
+
+// HeaderNotExists 判断报头 key 是否不存在
+func (resp *Response) HeaderNotExists(key string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	v, found := resp.resp.Header[http.CanonicalHeaderKey(key)]
+	return resp.assert(!found, assert.NewFailure("HeaderNotExists", msg, map[string]interface{}{"header": key, "v": v}))
+}
+
+// HeaderMatch 判断报头 key 的值是否匹配正则 reg
+func (resp *Response) HeaderMatch(key string, reg *regexp.Regexp, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := resp.resp.Header.Get(key)
+	return resp.assert(reg.MatchString(h), assert.NewFailure("HeaderMatch", msg, map[string]interface{}{"header": key, "v": h, "reg": reg}))
+}
+
+// ContentType 判断 Content-Type 报头是否与 val 相符
+//
+// val 和实际的报头都会经过 [mime.ParseMediaType] 解析之后再作比较：
+// 媒体类型必须相同，val 中指定的参数必须在报头中存在且值相同(不区分大小写)，
+// 报头中多出来的参数则会被忽略。比如 val 为 application/json 时，
+// 可以匹配 application/json;charset=utf-8。
+func (resp *Response) ContentType(val string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	h := resp.resp.Header.Get("Content-Type")
+	return resp.assert(matchMediaType(h, val), assert.NewFailure("ContentType", msg, map[string]interface{}{"v1": h, "v2": val}))
+}
+
+// 判断 mediatype 是否满足 expected 的要求
+func matchMediaType(mediatype, expected string) bool {
+	mt, params, err := mime.ParseMediaType(mediatype)
+	if err != nil {
+		return false
+	}
+
+	emt, eparams, err := mime.ParseMediaType(expected)
+	if err != nil || mt != emt {
+		return false
+	}
+
+	for k, v := range eparams {
+		if pv, found := params[k]; !found || !strings.EqualFold(pv, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// 将报头 key 的所有值按逗号拆分成列表
+func headerTokens(h http.Header, key string) []string {
+	var tokens []string
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+func containsToken(tokens []string, val string, fold bool) bool {
+	for _, t := range tokens {
+		if t == val || (fold && strings.EqualFold(t, val)) {
+			return true
+		}
+	}
+	return false
+}
+
 // Body 断言内容与 val 相同
 func (resp *Response) Body(val []byte, msg ...interface{}) *Response {
 	resp.a.TB().Helper()
@@ -138,6 +387,60 @@ func (resp *Response) BodyEmpty(msg ...interface{}) *Response {
 	return resp.assert(len(resp.body) == 0, assert.NewFailure("BodyEmpty", msg, map[string]interface{}{"body": resp.body}))
 }
 
+// BodyContains 断言内容中包含 val
+func (resp *Response) BodyContains(val string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(bytes.Contains(resp.body, []byte(val)), assert.NewFailure("BodyContains", msg, map[string]interface{}{"body": string(resp.body), "val": val}))
+}
+
+// BodyNotContains 断言内容中不包含 val
+func (resp *Response) BodyNotContains(val string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(!bytes.Contains(resp.body, []byte(val)), assert.NewFailure("BodyNotContains", msg, map[string]interface{}{"body": string(resp.body), "val": val}))
+}
+
+// BodyMatch 断言内容匹配正则 reg
+func (resp *Response) BodyMatch(reg *regexp.Regexp, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(reg.Match(resp.body), assert.NewFailure("BodyMatch", msg, map[string]interface{}{"body": string(resp.body), "reg": reg}))
+}
+
+// BodyNotMatch 断言内容不匹配正则 reg
+func (resp *Response) BodyNotMatch(reg *regexp.Regexp, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(!reg.Match(resp.body), assert.NewFailure("BodyNotMatch", msg, map[string]interface{}{"body": string(resp.body), "reg": reg}))
+}
+
+// BodyLength 断言内容的长度为 l
+func (resp *Response) BodyLength(l int, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	return resp.assert(len(resp.body) == l, assert.NewFailure("BodyLength", msg, map[string]interface{}{"len": len(resp.body), "val": l}))
+}
+
+// StringBodyIgnoreSpace 断言在忽略空白字符差异的情况下内容与 val 相同
+//
+// 首尾的空白字符会被删除，中间连续的空白字符(包括换行符)会被当作一个空格处理。
+func (resp *Response) StringBodyIgnoreSpace(val string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	b := string(resp.body)
+	eq := strings.Join(strings.Fields(b), " ") == strings.Join(strings.Fields(val), " ")
+	return resp.assert(eq, assert.NewFailure("StringBodyIgnoreSpace", msg, map[string]interface{}{"body": b, "val": val}))
+}
+
+// StringBodyIgnoreLineEnding 断言在忽略换行符差异的情况下内容与 val 相同
+//
+// \r\n 和 \r 都会被当作 \n 处理。
+func (resp *Response) StringBodyIgnoreLineEnding(val string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	b := string(resp.body)
+	eq := normalizeLineEnding(b) == normalizeLineEnding(val)
+	return resp.assert(eq, assert.NewFailure("StringBodyIgnoreLineEnding", msg, map[string]interface{}{"body": b, "val": val}))
+}
+
+func normalizeLineEnding(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
 // BodyFunc 指定对 body 内容的断言方式
 func (resp *Response) BodyFunc(f func(a *assert.Assertion, body []byte)) *Response {
 	resp.a.TB().Helper()
@@ -6,6 +6,7 @@ package rest
 
 import (
 	"net/http"
+	"regexp"
 	"testing"
 
 	"github.com/issue9/assert/v4"
@@ -59,3 +60,59 @@ func TestResponse(t *testing.T) {
 		NotHeader("content-type", "invalid value").
 		BodyEmpty()
 }
+
+func TestResponse_Header(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept, Origin")
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("X-Request-Id", "abc-123")
+		w.Header().Set("X-Empty", "")
+	})
+
+	Get(a, "/").Do(h).
+		HeaderValues("vary", []string{"Accept, Origin", "Accept-Encoding"}).
+		HeaderContains("vary", "Origin").
+		HeaderContains("vary", "Accept-Encoding").
+		HeaderExists("x-empty").
+		HeaderNotExists("x-not-exists").
+		HeaderMatch("x-request-id", regexp.MustCompile(`^[a-z]+-\d+$`)).
+		ContentType("application/json").
+		ContentType("application/json;charset=utf-8")
+}
+
+func TestMatchMediaType(t *testing.T) {
+	a := assert.New(t, false)
+
+	a.True(matchMediaType("application/json", "application/json")).
+		True(matchMediaType("application/json; charset=utf-8", "application/json")).
+		True(matchMediaType("Application/JSON;charset=utf-8", "application/json; charset=UTF-8")).
+		False(matchMediaType("application/json", "application/json; charset=utf-8")).
+		False(matchMediaType("application/json; charset=gbk", "application/json; charset=utf-8")).
+		False(matchMediaType("text/json", "application/json")).
+		False(matchMediaType("", "application/json")).
+		False(matchMediaType("application/json", "application/"))
+
+	a.Equal(headerTokens(http.Header{"Vary": {"a, b", " c ,"}}, "vary"), []string{"a", "b", "c"}).
+		True(containsToken([]string{"GET", "POST"}, "POST", false)).
+		False(containsToken([]string{"GET", "POST"}, "post", false)).
+		True(containsToken([]string{"GET", "POST"}, "post", true))
+}
+
+func TestResponse_BodyAssertions(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := BuildHandler(a, http.StatusOK, "  {\r\n  \"id\": 5,\r\n  \"name\": \"n\"\r\n}\r\n", nil)
+	Get(a, "/").Do(h).
+		BodyContains(`"id": 5`).
+		BodyNotContains(`"id": 6`).
+		BodyMatch(regexp.MustCompile(`"id":\s*\d+`)).
+		BodyNotMatch(regexp.MustCompile(`"age"`)).
+		BodyLength(35).
+		StringBodyIgnoreSpace(`{ "id": 5, "name": "n" }`).
+		StringBodyIgnoreLineEnding("  {\n  \"id\": 5,\n  \"name\": \"n\"\r}\n")
+
+	a.Equal(normalizeLineEnding("1\r\n2\r3\n"), "1\n2\n3\n")
+}
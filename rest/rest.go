@@ -11,6 +11,8 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 
 	"github.com/issue9/assert/v4"
 )
@@ -41,6 +43,94 @@ func BuildHandlerFunc(a *assert.Assertion, code int, body string, headers map[st
 	}
 }
 
+// BuildSequenceHandler 生成按顺序调用 hs 的 [http.Handler] 对象
+//
+// 第 n 次请求由 hs[n-1] 处理，超出 hs 数量之后的请求都由最后一个元素处理。
+func BuildSequenceHandler(a *assert.Assertion, hs ...http.Handler) http.Handler {
+	if len(hs) == 0 {
+		panic("参数 hs 不能为空")
+	}
+
+	var mu sync.Mutex
+	var index int
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.TB().Helper()
+
+		mu.Lock()
+		h := hs[index]
+		if index < len(hs)-1 {
+			index++
+		}
+		mu.Unlock()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// BuildAssertHandler 生成对请求进行断言的 [http.Handler] 对象
+//
+// 按以下步骤断言请求的内容，之后交由 next 处理：
+//   - 如果 method 不为空，断言请求方法与 method 相同；
+//   - 断言 headers 中的每一个报头都与请求中的值相同；
+//   - 如果 body 不为 nil，断言请求内容与 body 相同；
+//
+// 断言在处理请求的 goroutine 中执行，所以 a 不应该是以 fatal 模式创建的。
+func BuildAssertHandler(a *assert.Assertion, method string, headers map[string]string, body []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.TB().Helper()
+
+		if method != "" {
+			a.Equal(r.Method, method, "请求方法的实际值 %s 与期望值 %s 不同", r.Method, method)
+		}
+
+		for k, v := range headers {
+			rv := r.Header.Get(k)
+			a.Equal(rv, v, "报头 %s 的实际值 %s 与期望值 %s 不同", k, rv, v)
+		}
+
+		if body != nil {
+			data, err := io.ReadAll(r.Body)
+			a.NotError(err).Equal(data, body, "请求内容的实际值与期望值不同\n%s\n\n%s\n", data, body)
+			r.Body = io.NopCloser(bytes.NewReader(data))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// BuildEchoHandler 生成将请求内容原样返回的 [http.Handler] 对象
+//
+// 返回内容包含以下部分：
+//   - 状态码为 200；
+//   - 请求的报头，不包含 Connection 和 Content-Length 等与连接相关的报头；
+//   - X-Echo-Method 和 X-Echo-Uri 报头，分别表示请求方法和请求地址；
+//   - 请求的内容；
+func BuildEchoHandler(a *assert.Assertion) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.TB().Helper()
+
+		for k, v := range r.Header {
+			switch k {
+			case "Connection", "Keep-Alive", "Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length":
+				continue
+			}
+			w.Header()[k] = v
+		}
+		w.Header().Set("X-Echo-Method", r.Method)
+		uri := r.RequestURI
+		if uri == "" { // 直接调用 ServeHTTP 时可能为空
+			uri = r.URL.RequestURI()
+		}
+		w.Header().Set("X-Echo-Uri", uri)
+
+		data, err := io.ReadAll(r.Body)
+		a.NotError(err)
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(data)
+		a.NotError(err)
+	})
+}
+
 func (srv *Server) RawHTTP(req, resp string) *Server {
 	srv.Assertion().TB().Helper()
 	RawHTTP(srv.Assertion(), srv.client, req, resp)
@@ -57,7 +147,21 @@ func (srv *Server) RawHTTP(req, resp string) *Server {
 //
 // 会忽略 HOST 报头，而是应该将主机部分直接写在请求地址中。
 //
-// respRaw 表示返回之后的原始数据；
+// respRaw 表示返回之后的原始数据。报头的值和内容中可以包含以下占位符：
+//   - {{any}} 任意内容，包括空值和换行符；
+//   - {{uuid}} UUID 格式的字符串；
+//   - {{number}} 整数或是小数；
+//   - {{regex expr}} 符合正则表达式 expr 的内容；
+//
+// 占位符可以添加 name= 前缀，比如 {{token=any}}，在 [Scenario] 中表示将匹配的内容捕获至变量 name。
+// 无法识别的 {{…}} 会被当作普通字符，所以模板输出的 <p>{{name}}</p> 之类的内容可以直接比较。
+// 值中包含占位符的报头必须存在，比如 Date: {{any}} 仅要求返回内容包含 Date 报头。
+// 内容中包含占位符时，不应该再指定 Content-Length 报头。
+//
+// 如果 respRaw 的 Content-Type 为 JSON、XML 或 application/x-www-form-urlencoded，
+// 且双方的内容都能正确解析，则按格式比较内容，忽略字段顺序、缩进和属性顺序等差异，
+// 此时占位符仅对字符串类型的值有效，断言失败时会列出所有不相同的路径。
+// 否则去掉首尾空白字符之后按字符串进行比较。
 //
 // NOTE: 仅判断状态码、报头和实际内容是否相同，而不是直接比较两个 http.Response 的值。
 func RawHTTP(a *assert.Assertion, client *http.Client, reqRaw, respRaw string) {
@@ -111,13 +215,22 @@ func readRaw(a *assert.Assertion, reqRaw, respRaw string) (*http.Request, *http.
 	return r, resp
 }
 
-func compare(a *assert.Assertion, resp *http.Response, status int, header http.Header, body io.Reader) {
+// 比较返回内容，并返回期望值中通过占位符捕获的变量。
+func compare(a *assert.Assertion, resp *http.Response, status int, header http.Header, body io.Reader) map[string]string {
+	vars := map[string]string{}
+
 	a.Equal(resp.StatusCode, status, "compare 断言失败，状态码的期望值 %d 与实际值 %d 不同", resp.StatusCode, status)
 
 	for k := range resp.Header {
 		respV := resp.Header.Get(k)
 		retV := header.Get(k)
-		a.Equal(respV, retV, "compare 断言失败，报头 %s 的期望值 %s 与实际值 %s 不相同", k, respV, retV)
+
+		if expr, _ := compilePlaceholder(respV); expr != nil {
+			a.True(len(header.Values(k)) > 0, "compare 断言失败，报头 %s 不存在", k)
+		}
+		ok, err := matchPlaceholder(respV, retV, vars)
+		a.NotError(err, "compare 断言失败，报头 %s 的期望值 %s 格式错误：%s", k, respV, err).
+			True(ok, "compare 断言失败，报头 %s 的期望值 %s 与实际值 %s 不相同", k, respV, retV)
 	}
 
 	retB, err := io.ReadAll(body)
@@ -126,5 +239,17 @@ func compare(a *assert.Assertion, resp *http.Response, status int, header http.H
 	a.NotError(err).NotNil(respB)
 	retB = bytes.TrimSpace(retB)
 	respB = bytes.TrimSpace(respB)
-	a.Equal(respB, retB, "compare 断言失败，内容的期望值与实际值不相同\n%s\n\n%s\n", respB, retB)
+
+	diffs, handled, err := diffBody(resp.Header.Get("Content-Type"), respB, retB, vars)
+	if handled {
+		a.NotError(err, "compare 断言失败，内容的期望值格式错误：%s", err).
+			Empty(diffs, "compare 断言失败，内容的期望值与实际值不相同\n%s\n", strings.Join(diffs, "\n"))
+		return vars
+	}
+
+	ok, err := matchPlaceholder(string(respB), string(retB), vars)
+	a.NotError(err, "compare 断言失败，内容的期望值格式错误：%s", err).
+		True(ok, "compare 断言失败，内容的期望值与实际值不相同\n%s\n\n%s\n", respB, retB)
+
+	return vars
 }
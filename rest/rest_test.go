@@ -11,7 +11,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/issue9/assert/v4"
@@ -22,6 +24,53 @@ type bodyTest struct {
 	ID      int      `json:"id" xml:"id"`
 }
 
+// 记录断言失败信息的 testing.TB，用于测试断言失败的情况。
+type failTB struct {
+	testing.TB
+	mu       sync.Mutex
+	errors   []string
+	cleanups []func()
+}
+
+func (t *failTB) Helper() {}
+
+func (t *failTB) Error(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *failTB) Fatal(args ...interface{}) { t.Error(args...) }
+
+// 与 testing.T 相同，会结束当前 goroutine，需要在单独的 goroutine 中调用。
+func (t *failTB) FailNow() { runtime.Goexit() }
+
+// 在单独的 goroutine 中执行 f，返回 f 是否正常结束而不是调用了 FailNow。
+func (t *failTB) run(f func()) bool {
+	done := make(chan bool)
+	go func() {
+		completed := false
+		defer func() { done <- completed }()
+		f()
+		completed = true
+	}()
+	return <-done
+}
+
+func (t *failTB) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+// 执行所有的 Cleanup 并返回所有的失败信息
+func (t *failTB) finish() []string {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+	t.cleanups = nil
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.errors
+}
+
 var h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/get" {
 		w.WriteHeader(http.StatusCreated)
@@ -104,6 +153,59 @@ func TestBuildHandler(t *testing.T) {
 		Equal(w.Header().Get("k1"), "v1")
 }
 
+func TestBuildSequenceHandler(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := BuildSequenceHandler(a,
+		BuildHandler(a, http.StatusServiceUnavailable, "", nil),
+		BuildHandler(a, http.StatusOK, "ok", nil),
+	)
+	NewRequest(a, http.MethodGet, "/").Do(h).Status(http.StatusServiceUnavailable)
+	NewRequest(a, http.MethodGet, "/").Do(h).Status(http.StatusOK).StringBody("ok")
+	NewRequest(a, http.MethodGet, "/").Do(h).Status(http.StatusOK).StringBody("ok")
+
+	a.Panic(func() {
+		BuildSequenceHandler(a)
+	})
+}
+
+func TestBuildAssertHandler(t *testing.T) {
+	a := assert.New(t, false)
+
+	h := BuildAssertHandler(a, http.MethodPost, map[string]string{"Content-Type": "application/json"}, []byte(`{"id":5}`), BuildEchoHandler(a))
+	srv := NewServer(a, h, nil)
+	srv.Post("/path", []byte(`{"id":5}`)).
+		Header("Content-Type", "application/json").
+		Do(nil).
+		Success().
+		StringBody(`{"id":5}`)
+
+	h = BuildAssertHandler(a, "", nil, nil, BuildHandler(a, http.StatusCreated, "", nil))
+	NewRequest(a, http.MethodDelete, "/").Do(h).Status(http.StatusCreated)
+}
+
+func TestBuildEchoHandler(t *testing.T) {
+	a := assert.New(t, false)
+	h := BuildEchoHandler(a)
+
+	NewRequest(a, http.MethodPut, "/path").
+		Query("k", "v").
+		Header("X-Test", "test").
+		StringBody("body").
+		Do(h).
+		Status(http.StatusOK).
+		Header("X-Test", "test").
+		Header("X-Echo-Method", http.MethodPut).
+		Header("X-Echo-Uri", "/path?k=v").
+		StringBody("body")
+
+	srv := NewServer(a, h, nil)
+	srv.Get("/get").Do(nil).
+		Header("X-Echo-Method", http.MethodGet).
+		Header("X-Echo-Uri", "/get").
+		BodyEmpty()
+}
+
 var raw = []*struct {
 	req, resp string
 }{
@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/issue9/assert/v4"
+)
+
+var (
+	varRef        = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+	contentLength = regexp.MustCompile(`(?im)^content-length:[ \t]*([0-9]+)[ \t]*\r?$`)
+)
+
+// Scenario 按顺序执行的一组原始 HTTP 请求
+//
+// 可以从返回内容中捕获变量，之后的请求和期望的返回内容中可以通过 ${name} 的形式引用这些变量。
+// 捕获变量的方式有以下几种：
+//   - 在期望的返回内容中使用带变量名的占位符，比如 {{token=any}}，具体可参考 [RawHTTP]；
+//   - 通过 [Scenario.Step] 的 captures 参数指定，比如 [CaptureHeader] 和 [CaptureJSON] 等；
+type Scenario struct {
+	a      *assert.Assertion
+	client *http.Client
+	h      http.Handler
+	vars   map[string]string
+}
+
+// Capture 从返回内容中捕获变量
+type Capture struct {
+	name string
+	f    func(http.Header, []byte) (string, error)
+}
+
+// NewScenario 声明 [Scenario] 对象
+//
+// 如果 h 不为空，请求将由 h 处理，否则通过 client 发送，client 为空时采用 &http.Client{}。
+func NewScenario(a *assert.Assertion, client *http.Client, h http.Handler) *Scenario {
+	if client == nil && h == nil {
+		client = &http.Client{}
+	}
+
+	return &Scenario{
+		a:      a,
+		client: client,
+		h:      h,
+		vars:   map[string]string{},
+	}
+}
+
+// Scenario 声明基于当前服务的 [Scenario] 对象
+//
+// 变量 host 会被初始化为 [Server.URL] 的值。
+func (srv *Server) Scenario() *Scenario {
+	return NewScenario(srv.Assertion(), srv.client, nil).Set("host", srv.URL())
+}
+
+// Set 设置变量
+func (s *Scenario) Set(name, val string) *Scenario {
+	s.vars[name] = val
+	return s
+}
+
+// Get 获取变量的值
+func (s *Scenario) Get(name string) string { return s.vars[name] }
+
+// Step 执行一次请求
+//
+// reqRaw 和 respRaw 的格式与 [RawHTTP] 相同，其中的 ${name} 会被替换为对应变量的值，
+// 如果指定了 Content-Length 报头，其值也会根据替换后的内容重新计算。
+// captures 表示在返回内容比较完成之后，需要从返回内容中捕获的变量。
+func (s *Scenario) Step(reqRaw, respRaw string, captures ...*Capture) *Scenario {
+	a := s.a
+	a.TB().Helper()
+
+	reqRaw, err := s.expand(reqRaw)
+	a.NotError(err)
+	respRaw, err = s.expand(respRaw)
+	a.NotError(err)
+
+	r, resp := readRaw(a, reqRaw, respRaw)
+	if r == nil {
+		return s
+	}
+
+	var status int
+	var header http.Header
+	var body []byte
+	if s.h != nil {
+		w := httptest.NewRecorder()
+		s.h.ServeHTTP(w, r)
+		status, header, body = w.Code, w.Header(), w.Body.Bytes()
+	} else {
+		ret, err := s.client.Do(r)
+		a.NotError(err).NotNil(ret)
+		body, err = io.ReadAll(ret.Body)
+		a.NotError(err).NotError(ret.Body.Close())
+		status, header = ret.StatusCode, ret.Header
+	}
+
+	for k, v := range compare(a, resp, status, header, bytes.NewReader(body)) {
+		s.vars[k] = v
+	}
+
+	for _, c := range captures {
+		v, err := c.f(header, body)
+		a.NotError(err, "无法捕获变量 %s：%s", c.name, err)
+		s.vars[c.name] = v
+	}
+
+	return s
+}
+
+// 替换 raw 中的变量引用并重新计算 Content-Length
+func (s *Scenario) expand(raw string) (string, error) {
+	var err error
+	expand := func(str string) string {
+		return varRef.ReplaceAllStringFunc(str, func(ref string) string {
+			name := varRef.FindStringSubmatch(ref)[1]
+			v, found := s.vars[name]
+			if !found && err == nil {
+				err = fmt.Errorf("变量 %s 未定义", name)
+			}
+			return v
+		})
+	}
+
+	index := strings.Index(raw, "\n\n")
+	if i := strings.Index(raw, "\r\n\r\n"); i >= 0 && (index < 0 || i < index) {
+		index = i + 2
+	}
+	if index < 0 {
+		raw = expand(raw)
+		return raw, err
+	}
+	head, body := raw[:index+2], raw[index+2:]
+
+	if m := contentLength.FindStringSubmatchIndex(head); m != nil {
+		size, e := strconv.Atoi(head[m[2]:m[3]])
+		if e != nil {
+			return "", e
+		}
+		if size > len(body) {
+			size = len(body)
+		}
+
+		content := expand(body[:size])
+		head = head[:m[2]] + strconv.Itoa(len(content)) + head[m[3]:]
+		body = content + expand(body[size:])
+	} else {
+		body = expand(body)
+	}
+
+	head = expand(head)
+	return head + body, err
+}
+
+// CaptureHeader 将报头 key 的值捕获至变量 name
+func CaptureHeader(name, key string) *Capture {
+	return &Capture{
+		name: name,
+		f: func(h http.Header, _ []byte) (string, error) {
+			if len(h.Values(key)) == 0 {
+				return "", fmt.Errorf("报头 %s 不存在", key)
+			}
+			return h.Get(key), nil
+		},
+	}
+}
+
+// CaptureJSON 将 JSON 格式的内容中 path 指向的值捕获至变量 name
+//
+// path 以点号分隔各级字段，数组以数字作为下标，可以有 $. 前缀，比如 $.data.items.0.id。
+// 如果指向的值不是字符串，则以 JSON 格式保存。
+func CaptureJSON(name, path string) *Capture {
+	return &Capture{
+		name: name,
+		f: func(_ http.Header, body []byte) (string, error) {
+			d := json.NewDecoder(bytes.NewReader(body))
+			d.UseNumber()
+			var v interface{}
+			if err := d.Decode(&v); err != nil {
+				return "", err
+			}
+
+			v, err := jsonPath(v, path)
+			if err != nil {
+				return "", err
+			}
+
+			switch vv := v.(type) {
+			case string:
+				return vv, nil
+			case json.Number:
+				return vv.String(), nil
+			default:
+				data, err := json.Marshal(vv)
+				return string(data), err
+			}
+		},
+	}
+}
+
+// CaptureRegexp 将内容中匹配 expr 的值捕获至变量 name
+//
+// 如果 expr 包含子表达式，则捕获第一个子表达式的值，否则捕获整个匹配项。
+func CaptureRegexp(name string, expr *regexp.Regexp) *Capture {
+	return &Capture{
+		name: name,
+		f: func(_ http.Header, body []byte) (string, error) {
+			matches := expr.FindSubmatch(body)
+			if matches == nil {
+				return "", fmt.Errorf("内容不匹配 %s", expr)
+			}
+			if len(matches) > 1 {
+				return string(matches[1]), nil
+			}
+			return string(matches[0]), nil
+		},
+	}
+}
+
+func jsonPath(v interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return v, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			val, found := vv[key]
+			if !found {
+				return nil, fmt.Errorf("字段 %s 不存在", key)
+			}
+			v = val
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(vv) {
+				return nil, fmt.Errorf("无效的数组下标 %s", key)
+			}
+			v = vv[index]
+		default:
+			return nil, fmt.Errorf("无法在 %T 中查找 %s", v, key)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func scenarioHandler(a *assert.Assertion) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		a.NotError(err)
+		if string(data) != `{"user":"admin"}` {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Session", "s-1")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token":"t-123","user":{"id":7,"roles":["admin"]}}`))
+	})
+	mux.HandleFunc("/users/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t-123" || r.Header.Get("X-Session") != "s-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		a.NotError(err)
+		w.Write([]byte("name=" + string(data)))
+	})
+	return mux
+}
+
+func TestScenario(t *testing.T) {
+	a := assert.New(t, false)
+
+	s := NewScenario(a, nil, scenarioHandler(a)).Set("user", "admin").Set("name", "管理员")
+	s.Step(`POST http://localhost/login HTTP/1.1
+Content-Length: 18
+
+{"user":"${user}"}
+
+`, `HTTP/1.1 200
+X-Session: {{session=any}}
+
+{"token":"{{token=regex [a-z0-9-]+}}","user":{{any}}}
+`,
+		CaptureJSON("id", "$.user.id"),
+		CaptureJSON("roles", "user.roles"),
+		CaptureHeader("ct", "Content-Type"),
+		CaptureRegexp("token2", regexp.MustCompile(`"token":"([^"]+)"`)),
+	)
+	a.Equal(s.Get("session"), "s-1").
+		Equal(s.Get("token"), "t-123").
+		Equal(s.Get("token2"), "t-123").
+		Equal(s.Get("id"), "7").
+		Equal(s.Get("roles"), `["admin"]`).
+		Equal(s.Get("ct"), "application/json")
+
+	s.Step(`PUT http://localhost/users/${id} HTTP/1.1
+Authorization: Bearer ${token}
+X-Session: ${session}
+Content-Length: 7
+
+${name}
+
+`, `HTTP/1.1 200
+
+name=${name}
+`)
+}
+
+func TestServer_Scenario(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, scenarioHandler(a), nil)
+
+	s := srv.Scenario()
+	a.Equal(s.Get("host"), srv.URL())
+	s.Step(`POST ${host}/login HTTP/1.1
+Content-Length: 16
+
+{"user":"admin"}
+
+`, `HTTP/1.1 200
+
+{"token":"{{token=any}}","user":{{any}}}
+`).Step(`GET ${host}/users/7 HTTP/1.1
+Authorization: Bearer ${token}
+X-Session: s-1
+
+`, `HTTP/1.1 200
+
+name=
+`)
+}
+
+func TestScenario_expand(t *testing.T) {
+	a := assert.New(t, false)
+	s := NewScenario(a, nil, nil).Set("v", "12345")
+
+	raw, err := s.expand("POST /${v} HTTP/1.1\r\nContent-Length: 6\r\n\r\n${v}\r\n\r\n")
+	a.NotError(err).Equal(raw, "POST /12345 HTTP/1.1\r\nContent-Length: 7\r\n\r\n12345\r\n\r\n")
+
+	raw, err = s.expand("GET /${v} HTTP/1.1\n\n")
+	a.NotError(err).Equal(raw, "GET /12345 HTTP/1.1\n\n")
+
+	_, err = s.expand("GET /${not_exists} HTTP/1.1\n\n")
+	a.Error(err)
+}
+
+func TestJSONPath(t *testing.T) {
+	a := assert.New(t, false)
+	v := map[string]interface{}{"list": []interface{}{"a", map[string]interface{}{"b": "c"}}}
+
+	val, err := jsonPath(v, "$.list.1.b")
+	a.NotError(err).Equal(val, "c")
+
+	val, err = jsonPath(v, "$")
+	a.NotError(err).Equal(val, v)
+
+	_, err = jsonPath(v, "list.2")
+	a.Error(err)
+	_, err = jsonPath(v, "x")
+	a.Error(err)
+	_, err = jsonPath(v, "list.0.x")
+	a.Error(err)
+}
@@ -5,8 +5,12 @@
 package rest
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"time"
 
 	"github.com/issue9/assert/v4"
 )
@@ -17,31 +21,89 @@ type Server struct {
 	server *httptest.Server
 	client *http.Client
 	closed bool
+	isTLS  bool // 是否由 NewTLSServer 创建
+
+	recordsMu sync.Mutex
+	records   []*Record
+
+	// 在未指定 client 时，由选项指定的客户端构建方法。
+	newClient func() *http.Client
+
+	timeout time.Duration // 通过 NewRequest 等方法创建的请求的默认超时时间
+	har     *HAR
+	dumper  dumper
+
+	// 在未指定 client 时，默认客户端采用的 TLS 配置。
+	rootCAs     *x509.CertPool
+	clientCerts []tls.Certificate
+}
+
+// Option 初始化 [Server] 的选项
+//
+// 选项在服务启动之前执行。
+type Option func(*Server)
+
+// HTTP2 启用 HTTP/2 支持
+//
+// 仅对 [NewTLSServer] 有效，用于 [NewServer] 时不会有任何效果，未加密的 HTTP/2 可使用 [H2C]。
+// 如果未指定 client，会采用支持 HTTP/2 的客户端作为默认值。
+func HTTP2() Option {
+	return func(s *Server) { s.server.EnableHTTP2 = true }
+}
+
+// Timeout 指定请求的默认超时时间
+//
+// 通过 [Server.NewRequest] 等方法创建的请求，默认采用此值作为 [Request.Timeout] 的值。
+func Timeout(d time.Duration) Option {
+	return func(s *Server) { s.timeout = d }
 }
 
 // NewServer 声明新的测试服务
 //
-// 如果 client 为 nil，则会采用 &http.Client{} 作为默认值
-func NewServer(a *assert.Assertion, h http.Handler, client *http.Client) *Server {
-	return newServer(a, httptest.NewServer(h), client)
+// 如果 client 为 nil，则会采用 &http.Client{} 作为默认值。
+// 服务端接收到的所有请求都会被记录下来，可以通过 [Server.Records] 等方法进行查询和断言，
+// 记录不会提前读取请求内容，处理函数未读取的部分在处理完成之后才会读取，所以不影响流式处理的处理函数。
+func NewServer(a *assert.Assertion, h http.Handler, client *http.Client, o ...Option) *Server {
+	return newServer(a, h, client, false, o)
 }
 
 // NewTLSServer 声明新的测试服务
 //
 // 如果 client 为 nil，则会采用 &http.Client{} 作为默认值
-func NewTLSServer(a *assert.Assertion, h http.Handler, client *http.Client) *Server {
-	return newServer(a, httptest.NewTLSServer(h), client)
+func NewTLSServer(a *assert.Assertion, h http.Handler, client *http.Client, o ...Option) *Server {
+	return newServer(a, h, client, true, o)
 }
 
-func newServer(a *assert.Assertion, srv *httptest.Server, client *http.Client) *Server {
-	if client == nil {
-		client = &http.Client{}
-	}
-
+func newServer(a *assert.Assertion, h http.Handler, client *http.Client, isTLS bool, o []Option) *Server {
 	s := &Server{
 		a:      a,
-		server: srv,
+		server: httptest.NewUnstartedServer(h),
 		client: client,
+		isTLS:  isTLS,
+		dumper: newDumper(),
+	}
+
+	for _, opt := range o {
+		opt(s)
+	}
+	s.server.Config.Handler = s.record(s.server.Config.Handler)
+
+	if isTLS {
+		s.server.StartTLS()
+	} else {
+		s.server.Start()
+	}
+
+	if s.client == nil {
+		switch {
+		case s.newClient != nil:
+			s.client = s.newClient()
+		case isTLS && (s.server.EnableHTTP2 || s.rootCAs != nil || s.clientCerts != nil):
+			s.client = s.server.Client()
+			s.client.Transport.(*http.Transport).TLSClientConfig = s.clientTLSConfig()
+		default:
+			s.client = &http.Client{}
+		}
 	}
 
 	a.TB().Cleanup(func() {
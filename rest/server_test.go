@@ -28,3 +28,17 @@ func TestNew(t *testing.T) {
 	a.True(srv.closed)
 	srv.Close()
 }
+
+func TestHTTP2(t *testing.T) {
+	a := assert.New(t, false)
+
+	srv := NewTLSServer(a, h, nil, HTTP2())
+	srv.Get("/get").Do(nil).Status(http.StatusCreated).Proto(2, 0)
+
+	srv = NewServer(a, h, nil)
+	srv.Get("/get").Do(nil).Status(http.StatusCreated).Proto(1, 1)
+
+	// 对 NewServer 无效
+	srv = NewServer(a, h, nil, HTTP2())
+	srv.Get("/get").Do(nil).Status(http.StatusCreated).Proto(1, 1)
+}
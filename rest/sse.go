@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// Event SSE 中的事件
+type Event struct {
+	ID    string // 最后一次指定的 id 值，即使当前事件未指定，也会继承之前的值。
+	Event string // 事件类型，为空表示未指定，即默认的 message 类型。
+	Data  string // 多行 data 会以 \n 相连。
+	Retry int    // 重连时间，单位为毫秒，0 表示未指定。
+}
+
+// EventSource 以流的方式读取 text/event-stream 内容
+type EventSource struct {
+	a      *assert.Assertion
+	resp   *http.Response
+	cancel context.CancelFunc
+	events chan *Event
+	err    error // 读取过程中发生的错误，在 events 关闭之后才可读。
+	done   chan struct{}
+	once   sync.Once
+}
+
+// SSE 发送请求并以 SSE 的方式读取返回内容
+//
+// 与 [Request.Do] 不同，SSE 不会等待读取所有的内容，而是在接收到报头之后即返回，
+// 之后的事件由 [EventSource] 逐条读取。如果未指定 Accept 报头，会自动添加 text/event-stream。
+//
+// 仅支持通过 [http.Client] 发送请求，如果未指定 [Request.Client]，将会 panic。
+// 连接会在 [EventSource.Close] 或是 testing.TB.Cleanup 中关闭。
+func (req *Request) SSE() *EventSource {
+	if req.client == nil {
+		panic("client 不能为空")
+	}
+
+	req.a.TB().Helper()
+
+	r := req.Request()
+	if r.Header.Get("Accept") == "" {
+		r.Header.Set("Accept", "text/event-stream")
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	r = r.WithContext(ctx)
+
+	es := &EventSource{
+		a:      req.a,
+		cancel: cancel,
+		events: make(chan *Event, 10),
+		done:   make(chan struct{}),
+	}
+	req.a.TB().Cleanup(es.Close)
+
+	resp, err := req.client.Do(r)
+	req.a.NotError(err).NotNil(resp)
+	if err != nil {
+		es.err = err
+		close(es.events)
+		return es
+	}
+	es.resp = resp
+
+	go es.read(resp.Body)
+
+	return es
+}
+
+// Resp 返回 [http.Response] 实例
+//
+// NOTE: [http.Response.Body] 由 [EventSource] 负责读取，不应该再直接操作。
+func (es *EventSource) Resp() *http.Response { return es.resp }
+
+func (es *EventSource) read(r io.Reader) {
+	defer close(es.events)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 4096), maxEventLineSize)
+	s.Split(scanEventLines())
+
+	var id, event string
+	var retry int
+	var data []string
+	for s.Scan() {
+		line := s.Text()
+		if line == "" { // 空行表示一个事件的结束
+			if len(data) > 0 {
+				e := &Event{ID: id, Event: event, Data: strings.Join(data, "\n"), Retry: retry}
+				select {
+				case es.events <- e:
+				case <-es.done:
+					return
+				}
+			}
+			event, retry, data = "", 0, data[:0]
+			continue
+		}
+
+		if line[0] == ':' { // 注释
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field = line[:i]
+			value = strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				id = value
+			}
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				retry = n
+			}
+		}
+	}
+	es.err = s.Err()
+}
+
+// 单行内容的最大长度
+const maxEventLineSize = 16 << 20
+
+// 按行分割事件流
+//
+// 行可以以 \r\n、\n 或是 \r 结尾，未以换行符结尾的内容不是完整的行，直接丢弃。
+func scanEventLines() bufio.SplitFunc {
+	var skipLF bool // 上一行以 \r 结尾，如果紧接着是 \n，两者属于同一个换行符。
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		start := 0
+		if skipLF && len(data) > 0 {
+			skipLF = false
+			if data[0] == '\n' {
+				start = 1
+			}
+		}
+
+		if i := bytes.IndexAny(data[start:], "\r\n"); i >= 0 {
+			i += start
+			skipLF = data[i] == '\r'
+			return i + 1, data[start:i], nil
+		}
+		return start, nil, nil
+	}
+}
+
+// Next 等待并返回下一个事件
+//
+// 如果在 timeout 时间内未接收到事件或是连接已经断开，断言失败并返回 nil。
+func (es *EventSource) Next(timeout time.Duration, msg ...interface{}) *Event {
+	es.a.TB().Helper()
+
+	select {
+	case e, ok := <-es.events:
+		if !ok {
+			es.a.Assert(false, assert.NewFailure("Next", msg, map[string]interface{}{"err": es.err}))
+			return nil
+		}
+		return e
+	case <-time.After(timeout):
+		es.a.Assert(false, assert.NewFailure("Next", msg, map[string]interface{}{"timeout": timeout}))
+		return nil
+	}
+}
+
+// Expect 断言在 timeout 时间内接收到的下一个事件与 e 相同
+func (es *EventSource) Expect(timeout time.Duration, e *Event, msg ...interface{}) *EventSource {
+	es.a.TB().Helper()
+	if next := es.Next(timeout, msg...); next != nil {
+		es.a.Assert(*next == *e, assert.NewFailure("Expect", msg, map[string]interface{}{"v1": next, "v2": e}))
+	}
+	return es
+}
+
+// ExpectData 断言在 timeout 时间内接收到的下一个事件的内容为 data
+func (es *EventSource) ExpectData(timeout time.Duration, data string, msg ...interface{}) *EventSource {
+	es.a.TB().Helper()
+	if next := es.Next(timeout, msg...); next != nil {
+		es.a.Assert(next.Data == data, assert.NewFailure("ExpectData", msg, map[string]interface{}{"v1": next.Data, "v2": data}))
+	}
+	return es
+}
+
+// End 断言在 timeout 时间内连接被服务端正常关闭且没有再接收到事件
+func (es *EventSource) End(timeout time.Duration, msg ...interface{}) *EventSource {
+	es.a.TB().Helper()
+
+	select {
+	case e, ok := <-es.events:
+		if ok {
+			es.a.Assert(false, assert.NewFailure("End", msg, map[string]interface{}{"event": e}))
+		} else {
+			es.a.Assert(es.err == nil, assert.NewFailure("End", msg, map[string]interface{}{"err": es.err}))
+		}
+	case <-time.After(timeout):
+		es.a.Assert(false, assert.NewFailure("End", msg, map[string]interface{}{"timeout": timeout}))
+	}
+	return es
+}
+
+// Close 关闭连接
+//
+// 如果未手动调用，则在 testing.TB.Cleanup 中自动调用。
+func (es *EventSource) Close() {
+	es.once.Do(func() {
+		close(es.done)
+		es.cancel()
+		if es.resp != nil {
+			es.a.NotError(es.resp.Body.Close())
+		}
+	})
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+func sseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Accept") != "text/event-stream" {
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	f := w.(http.Flusher)
+
+	w.Write([]byte(": comment\n\ndata: 1\n\n"))
+	f.Flush()
+	time.Sleep(10 * time.Millisecond)
+
+	w.Write([]byte("id: 2\r\nevent: update\r\nretry: 500\r\ndata: line1\r\ndata:line2\r\n\r\n"))
+	f.Flush()
+
+	w.Write([]byte("data: 3\n\nevent: empty\n\n"))
+	f.Flush()
+
+	if r.URL.Path == "/wait" {
+		<-r.Context().Done()
+	}
+}
+
+func TestRequest_SSE(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, http.HandlerFunc(sseHandler), nil)
+
+	es := srv.Get("/sse").SSE()
+	a.Equal(es.Resp().StatusCode, http.StatusOK)
+	es.ExpectData(time.Second, "1").
+		Expect(time.Second, &Event{ID: "2", Event: "update", Data: "line1\nline2", Retry: 500}).
+		Expect(time.Second, &Event{ID: "2", Data: "3"}).
+		End(time.Second)
+	es.Close()
+	es.Close()
+
+	es = srv.Get("/wait").SSE()
+	e := es.Next(time.Second)
+	a.NotNil(e).Equal(e.Data, "1")
+	es.Close()
+
+	a.Panic(func() {
+		Get(a, "/sse").SSE()
+	})
+}
+
+func TestRequest_SSE_lineEndings(t *testing.T) {
+	a := assert.New(t, false)
+	srv := NewServer(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		f := w.(http.Flusher)
+
+		// 仅以 \r 结尾，事件不需要等待后续内容。
+		w.Write([]byte("data: 1\r\r"))
+		f.Flush()
+		time.Sleep(10 * time.Millisecond)
+
+		// \r\n 被分在两次写入中
+		w.Write([]byte("data: 2\r"))
+		f.Flush()
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("\ndata: 3\r\n\n"))
+		f.Flush()
+
+		<-r.Context().Done()
+	}), nil)
+
+	srv.Get("/").SSE().
+		ExpectData(time.Second, "1").
+		ExpectData(time.Second, "2\n3")
+}
+
+func TestScanEventLines(t *testing.T) {
+	a := assert.New(t, false)
+
+	s := bufio.NewScanner(strings.NewReader("a\r\nb\r\n\r\nc\rd\n\r\re"))
+	s.Split(scanEventLines())
+	var lines []string
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	a.NotError(s.Err()).
+		Equal(lines, []string{"a", "b", "", "c", "d", "", ""}) // e 未以换行符结尾
+}
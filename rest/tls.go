@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// CA 在内存中生成的证书颁发机构
+//
+// 可用于签发测试用的服务端和客户端证书。
+type CA struct {
+	a    *assert.Assertion
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+// NewCA 声明 [CA] 对象
+//
+// 证书的有效期为从当前时间开始的 24 小时。
+func NewCA(a *assert.Assertion) *CA {
+	a.TB().Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	a.NotError(err).NotNil(key)
+
+	tmpl := certTemplate(a)
+	tmpl.Subject = pkix.Name{CommonName: "rest test CA"}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	a.NotError(err)
+	cert, err := x509.ParseCertificate(der)
+	a.NotError(err).NotNil(cert)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &CA{a: a, cert: cert, key: key, pool: pool}
+}
+
+func certTemplate(a *assert.Assertion) *x509.Certificate {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	a.NotError(err)
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+	}
+}
+
+// Certificate 返回 CA 自身的证书
+func (ca *CA) Certificate() *x509.Certificate { return ca.cert }
+
+// Pool 返回仅包含 CA 证书的 [x509.CertPool]
+func (ca *CA) Pool() *x509.CertPool { return ca.pool }
+
+// IssueServer 签发服务端证书
+//
+// hosts 为证书中包含的域名或是 IP 地址。
+func (ca *CA) IssueServer(hosts ...string) tls.Certificate {
+	ca.a.TB().Helper()
+
+	tmpl := certTemplate(ca.a)
+	tmpl.Subject = pkix.Name{CommonName: "rest test server"}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	return ca.issue(tmpl)
+}
+
+// IssueClient 签发客户端证书
+//
+// commonName 为证书的 CommonName 字段。
+func (ca *CA) IssueClient(commonName string) tls.Certificate {
+	ca.a.TB().Helper()
+
+	tmpl := certTemplate(ca.a)
+	tmpl.Subject = pkix.Name{CommonName: commonName}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return ca.issue(tmpl)
+}
+
+func (ca *CA) issue(tmpl *x509.Certificate) tls.Certificate {
+	ca.a.TB().Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca.a.NotError(err).NotNil(key)
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	ca.a.NotError(err)
+	leaf, err := x509.ParseCertificate(der)
+	ca.a.NotError(err).NotNil(leaf)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+func (srv *Server) tlsConfig() *tls.Config {
+	if srv.server.TLS == nil {
+		srv.server.TLS = &tls.Config{}
+	}
+	return srv.server.TLS
+}
+
+// ServerCert 采用由 ca 签发的服务端证书
+//
+// 仅对 [NewTLSServer] 有效。证书包含 127.0.0.1、::1 和 localhost，
+// 如果未指定 client，默认的客户端会信任 ca 签发的证书。
+func ServerCert(ca *CA) Option {
+	return func(s *Server) {
+		s.tlsConfig().Certificates = []tls.Certificate{ca.IssueServer("127.0.0.1", "::1", "localhost")}
+		s.rootCAs = ca.Pool()
+	}
+}
+
+// ClientAuth 要求客户端提供由 ca 签发的证书
+//
+// 仅对 [NewTLSServer] 有效。cert 为默认的客户端采用的证书，为空表示不提供证书，
+// 如果指定了 client，则 cert 会被忽略。
+func ClientAuth(ca *CA, cert *tls.Certificate) Option {
+	return func(s *Server) {
+		conf := s.tlsConfig()
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+		conf.ClientCAs = ca.Pool()
+
+		s.clientCerts = nil
+		if cert != nil {
+			s.clientCerts = []tls.Certificate{*cert}
+		}
+	}
+}
+
+// 生成访问当前服务的客户端 TLS 配置
+func (srv *Server) clientTLSConfig() *tls.Config {
+	pool := srv.rootCAs
+	if pool == nil {
+		pool = x509.NewCertPool()
+		pool.AddCert(srv.server.Certificate())
+	}
+	return &tls.Config{RootCAs: pool, Certificates: srv.clientCerts}
+}
+
+func (resp *Response) connState(action string, msg []interface{}) *tls.ConnectionState {
+	resp.a.TB().Helper()
+
+	if resp.resp.TLS == nil {
+		resp.assert(false, assert.NewFailure(action, msg, map[string]interface{}{"tls": nil}))
+	}
+	return resp.resp.TLS
+}
+
+// TLSVersion 断言连接采用的 TLS 版本为 v
+//
+// v 的取值为 [tls.VersionTLS12] 等常量。
+func (resp *Response) TLSVersion(v uint16, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	if s := resp.connState("TLSVersion", msg); s != nil {
+		resp.assert(s.Version == v, assert.NewFailure("TLSVersion", msg, map[string]interface{}{"v1": s.Version, "v2": v}))
+	}
+	return resp
+}
+
+// CipherSuite 断言连接采用的加密套件为 id
+//
+// id 的取值为 [tls.TLS_AES_128_GCM_SHA256] 等常量。
+func (resp *Response) CipherSuite(id uint16, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	if s := resp.connState("CipherSuite", msg); s != nil {
+		resp.assert(s.CipherSuite == id, assert.NewFailure("CipherSuite", msg, map[string]interface{}{"v1": tls.CipherSuiteName(s.CipherSuite), "v2": tls.CipherSuiteName(id)}))
+	}
+	return resp
+}
+
+// PeerCertificate 断言服务端证书的 CommonName 为 commonName
+func (resp *Response) PeerCertificate(commonName string, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+	if s := resp.connState("PeerCertificate", msg); s != nil {
+		var cn string
+		if len(s.PeerCertificates) > 0 {
+			cn = s.PeerCertificates[0].Subject.CommonName
+		}
+		resp.assert(len(s.PeerCertificates) > 0 && cn == commonName, assert.NewFailure("PeerCertificate", msg, map[string]interface{}{"v1": cn, "v2": commonName}))
+	}
+	return resp
+}
+
+// VerifiedBy 断言服务端的证书链可以由 ca 验证
+func (resp *Response) VerifiedBy(ca *CA, msg ...interface{}) *Response {
+	resp.a.TB().Helper()
+
+	s := resp.connState("VerifiedBy", msg)
+	if s == nil {
+		return resp
+	}
+
+	var err error
+	if len(s.PeerCertificates) == 0 {
+		err = errors.New("服务端未提供证书")
+	} else {
+		inter := x509.NewCertPool()
+		for _, c := range s.PeerCertificates[1:] {
+			inter.AddCert(c)
+		}
+		_, err = s.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: ca.Pool(), Intermediates: inter})
+	}
+	return resp.assert(err == nil, assert.NewFailure("VerifiedBy", msg, map[string]interface{}{"err": err}))
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+var clientCNHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+})
+
+func TestCA(t *testing.T) {
+	a := assert.New(t, false)
+
+	ca := NewCA(a)
+	a.NotNil(ca).
+		True(ca.Certificate().IsCA).
+		NotNil(ca.Pool())
+
+	cert := ca.IssueServer("127.0.0.1", "localhost")
+	a.Length(cert.Certificate, 2).
+		Equal(cert.Leaf.DNSNames, []string{"localhost"}).
+		Length(cert.Leaf.IPAddresses, 1).
+		NotError(cert.Leaf.CheckSignatureFrom(ca.Certificate()))
+
+	cert = ca.IssueClient("client")
+	a.Equal(cert.Leaf.Subject.CommonName, "client").
+		NotError(cert.Leaf.CheckSignatureFrom(ca.Certificate()))
+}
+
+func TestServer_TLS(t *testing.T) {
+	a := assert.New(t, false)
+	ca := NewCA(a)
+	cert := ca.IssueClient("client")
+
+	srv := NewTLSServer(a, clientCNHandler, nil, ServerCert(ca), ClientAuth(ca, &cert), HTTP2())
+	resp := srv.Get("/").Do(nil).
+		Status(http.StatusOK).
+		StringBody("client").
+		Proto(2, 0).
+		TLSVersion(tls.VersionTLS13).
+		PeerCertificate("rest test server").
+		VerifiedBy(ca)
+
+	// 协商的加密套件与硬件相关，只能与同一连接的值进行比较。
+	resp.CipherSuite(resp.Resp().TLS.CipherSuite)
+	tb := &failTB{TB: t}
+	fake := &Response{a: assert.New(tb, false), resp: resp.Resp(), dumper: newDumper()}
+	fake.CipherSuite(tls.TLS_RSA_WITH_RC4_128_SHA)
+	a.Length(tb.finish(), 1)
+
+	// 未提供客户端证书
+	srv = NewTLSServer(a, clientCNHandler, nil, ServerCert(ca), ClientAuth(ca, nil))
+	_, err := srv.client.Get(srv.URL())
+	a.Error(err)
+
+	// 客户端证书由其它 CA 签发
+	other := NewCA(a).IssueClient("other")
+	srv = NewTLSServer(a, clientCNHandler, nil, ServerCert(ca), ClientAuth(ca, &other))
+	_, err = srv.client.Get(srv.URL())
+	a.Error(err)
+
+	// 仅采用 ClientAuth，服务端依然使用 httptest 的证书。
+	srv = NewTLSServer(a, wsHandlerWithCN(), nil, ClientAuth(ca, &cert))
+	srv.Get("/").Do(nil).Status(http.StatusOK).StringBody("client")
+	srv.WebSocket("/ws", http.Header{"X-Test": {"1"}}).
+		SendText("hello").
+		ExpectText(time.Second, "hello")
+}
+
+// 根据请求类型分别交由 wsHandler 和 clientCNHandler 处理
+func wsHandlerWithCN() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			wsHandler(w, r)
+			return
+		}
+		clientCNHandler(w, r)
+	})
+}
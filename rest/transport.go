@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// Transport 不经过网络的 [http.RoundTripper] 实现
+//
+// 请求会优先交由预设的响应处理，在预设的响应用完之后，交由 [http.Handler] 处理。
+// 可用于测试依赖 [http.Client] 的代码，也可以通过 [Request.Client] 与 [Request] 配合使用：
+//
+//	t := rest.NewTransport(h)
+//	rest.Get(a, "http://example.com/users").Client(t.Client()).Do(nil).Status(http.StatusOK)
+type Transport struct {
+	h     http.Handler
+	mu    sync.Mutex
+	steps []func(*http.Request) (*http.Response, error)
+}
+
+// NewTransport 声明 [Transport] 对象
+//
+// h 可以为空，表示只能使用预设的响应。
+func NewTransport(h http.Handler) *Transport { return &Transport{h: h} }
+
+// Client 返回以当前对象作为 [http.Client.Transport] 的客户端
+func (t *Transport) Client() *http.Client { return &http.Client{Transport: t} }
+
+// Reply 预设一次响应
+//
+// 多次调用会按调用顺序依次作为之后请求的响应，参数与 [BuildHandler] 相同。
+func (t *Transport) Reply(code int, body string, headers map[string]string) *Transport {
+	return t.step(func(r *http.Request) (*http.Response, error) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			for k, v := range headers {
+				w.Header().Add(k, v)
+			}
+			w.WriteHeader(code)
+			if body != "" {
+				_, _ = w.Write([]byte(body)) // ResponseRecorder 不会返回错误
+			}
+		})
+		return serve(h, r), nil
+	})
+}
+
+// ReplyError 预设一次传输层的错误
+//
+// err 可以是任意错误，也可以是 [TimeoutError]、[ConnResetError] 和 [DNSError] 等模拟的网络错误。
+func (t *Transport) ReplyError(err error) *Transport {
+	return t.step(func(*http.Request) (*http.Response, error) { return nil, err })
+}
+
+func (t *Transport) step(f func(*http.Request) (*http.Response, error)) *Transport {
+	t.mu.Lock()
+	t.steps = append(t.steps, f)
+	t.mu.Unlock()
+	return t
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil { // http.RoundTripper 要求在任何情况下都关闭请求内容
+		defer r.Body.Close()
+	}
+
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	var f func(*http.Request) (*http.Response, error)
+	if len(t.steps) > 0 {
+		f = t.steps[0]
+		t.steps = t.steps[1:]
+	}
+	t.mu.Unlock()
+
+	switch {
+	case f != nil:
+		return f(r)
+	case t.h != nil:
+		return serve(t.h, r), nil
+	default:
+		return nil, errors.New("rest.Transport: 没有可用的响应")
+	}
+}
+
+// 将客户端的请求 r 转换成服务端的请求交由 h 处理
+func serve(h http.Handler, r *http.Request) *http.Response {
+	req := r.Clone(r.Context())
+	req.RequestURI = r.URL.RequestURI()
+	req.RemoteAddr = "192.0.2.1:1234" // 与 httptest.NewRequest 相同
+	if req.Host == "" {
+		req.Host = r.URL.Host
+	}
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	resp := w.Result()
+	resp.Request = r
+	return resp
+}
+
+// TimeoutError 模拟网络超时的错误
+//
+// 可以通过 errors.Is(err, os.ErrDeadlineExceeded) 进行判断。
+func TimeoutError() error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+}
+
+// ConnResetError 模拟连接被重置的错误
+//
+// 可以通过 errors.Is(err, syscall.ECONNRESET) 进行判断。
+func ConnResetError() error {
+	return &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+}
+
+// DNSError 模拟域名 host 无法解析的错误
+func DNSError(host string) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}}
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/issue9/assert/v4"
+)
+
+func TestTransport(t *testing.T) {
+	a := assert.New(t, false)
+
+	tr := NewTransport(h).
+		Reply(http.StatusAccepted, "body", map[string]string{"X-Test": "1"}).
+		ReplyError(TimeoutError())
+	client := tr.Client()
+
+	Get(a, "http://example.com/get").Client(client).Do(nil).
+		Status(http.StatusAccepted).
+		Header("X-Test", "1").
+		StringBody("body")
+
+	resp, err := client.Get("http://example.com/get")
+	a.Error(err).Nil(resp).ErrorIs(err, os.ErrDeadlineExceeded)
+	var netErr net.Error
+	a.True(errors.As(err, &netErr)).True(netErr.Timeout())
+
+	// 预设的响应已用完，交由 h 处理。
+	Post(a, "http://example.com/body", []byte(`{"id":5}`)).
+		Header("content-type", "application/json").
+		Client(client).
+		Do(nil).
+		Status(http.StatusCreated).
+		StringBody(`{"id":6}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com/get", nil)
+	a.NotError(err)
+	resp, err = client.Do(r)
+	a.ErrorIs(err, context.Canceled).Nil(resp)
+
+	client = NewTransport(nil).ReplyError(ConnResetError()).ReplyError(DNSError("example.com")).Client()
+	_, err = client.Get("http://example.com/get")
+	a.ErrorIs(err, syscall.ECONNRESET)
+	_, err = client.Get("http://example.com/get")
+	var dnsErr *net.DNSError
+	a.True(errors.As(err, &dnsErr)).True(dnsErr.IsNotFound).Equal(dnsErr.Name, "example.com")
+	_, err = client.Get("http://example.com/get")
+	a.ErrorString(err, "没有可用的响应")
+}
+
+type closeBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *closeBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransport_RoundTrip_closeBody(t *testing.T) {
+	a := assert.New(t, false)
+
+	tr := NewTransport(nil).
+		Reply(http.StatusOK, "", nil).
+		ReplyError(TimeoutError())
+
+	roundTrip := func(ctx context.Context) *closeBody {
+		body := &closeBody{Reader: strings.NewReader("body")}
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.com/body", body)
+		a.NotError(err)
+		if resp, err := tr.RoundTrip(r); err == nil {
+			a.NotError(resp.Body.Close())
+		}
+		return body
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.True(roundTrip(ctx).closed, "context") // 不会消耗预设的响应
+
+	a.True(roundTrip(context.Background()).closed, "Reply").
+		True(roundTrip(context.Background()).closed, "ReplyError").
+		True(roundTrip(context.Background()).closed, "没有可用的响应")
+
+	tr = NewTransport(h)
+	a.True(roundTrip(context.Background()).closed, "handler")
+}
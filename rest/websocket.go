@@ -0,0 +1,427 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// WebSocket 的消息类型，即帧的 opcode 值
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	closeNoStatus = 1005 // 关闭帧中未包含状态码
+)
+
+// WebSocket 测试用的 WebSocket 客户端
+//
+// 仅实现了 RFC 6455 中与测试相关的部分，不支持扩展和子协议的协商。
+type WebSocket struct {
+	a      *assert.Assertion
+	conn   net.Conn
+	br     *bufio.Reader
+	resp   *http.Response
+	mu     sync.Mutex // 保证写入帧的完整性
+	closed bool       // 是否已经发送过关闭帧
+	once   sync.Once
+}
+
+// WebSocket 连接当前服务中 path 指向的 WebSocket 服务
+//
+// header 为握手时额外附加的报头，可以为空。
+// 如果是由 [NewTLSServer] 创建的服务，会自动信任其证书，
+// 且在指定了 [ClientAuth] 时采用其指定的客户端证书。
+func (srv *Server) WebSocket(path string, header http.Header) *WebSocket {
+	srv.a.TB().Helper()
+
+	var conf *tls.Config
+	if srv.server.TLS != nil {
+		conf = srv.clientTLSConfig()
+	}
+
+	return DialWebSocket(srv.a, srv.URL()+path, conf, header)
+}
+
+// DialWebSocket 连接 rawURL 指向的 WebSocket 服务
+//
+// rawURL 的协议可以是 ws、wss、http 和 https；
+// conf 为 wss 和 https 时采用的 TLS 配置，可以为空；
+// header 为握手时额外附加的报头，可以为空。
+//
+// 连接会在 [WebSocket.Close] 或是 testing.TB.Cleanup 中关闭。
+// 如果连接或是握手失败，断言失败，返回对象的其它方法均不再执行任何操作。
+func DialWebSocket(a *assert.Assertion, rawURL string, conf *tls.Config, header http.Header) *WebSocket {
+	a.TB().Helper()
+
+	u, err := url.Parse(rawURL)
+	a.NotError(err)
+	if err != nil {
+		return &WebSocket{a: a}
+	}
+
+	var secure bool
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+		secure = true
+	default:
+		panic("无效的协议 " + u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var conn net.Conn
+	if secure {
+		if conf == nil {
+			conf = &tls.Config{}
+		} else {
+			conf = conf.Clone()
+		}
+		if conf.ServerName == "" {
+			conf.ServerName = u.Hostname()
+		}
+		conn, err = tls.Dial("tcp", addr, conf)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	a.NotError(err)
+	if err != nil {
+		return &WebSocket{a: a}
+	}
+
+	key := make([]byte, 16)
+	_, err = rand.Read(key)
+	a.NotError(err)
+
+	r := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+	r.Header.Set("Sec-WebSocket-Version", "13")
+
+	br := bufio.NewReader(conn)
+	var resp *http.Response
+	if err = r.Write(conn); err == nil {
+		resp, err = http.ReadResponse(br, r)
+	}
+	a.NotError(err)
+	if err != nil {
+		conn.Close()
+		return &WebSocket{a: a}
+	}
+
+	ws := &WebSocket{a: a, conn: conn, br: br, resp: resp}
+	a.TB().Cleanup(ws.Close)
+
+	accept := resp.Header.Get("Sec-WebSocket-Accept")
+	a.Assert(resp.StatusCode == http.StatusSwitchingProtocols && accept == websocketAccept(r.Header.Get("Sec-WebSocket-Key")),
+		assert.NewFailure("DialWebSocket", nil, map[string]interface{}{"status": resp.StatusCode, "accept": accept}))
+
+	return ws
+}
+
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// Resp 返回握手时的 [http.Response] 实例
+func (ws *WebSocket) Resp() *http.Response { return ws.resp }
+
+func (ws *WebSocket) write(opcode byte, data []byte) *WebSocket {
+	ws.a.TB().Helper()
+
+	if ws.conn == nil {
+		return ws
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.a.NotError(writeFrame(ws.conn, opcode, data, true))
+	return ws
+}
+
+// SendText 发送文本消息
+func (ws *WebSocket) SendText(s string) *WebSocket {
+	ws.a.TB().Helper()
+	return ws.write(TextMessage, []byte(s))
+}
+
+// SendBinary 发送二进制消息
+func (ws *WebSocket) SendBinary(data []byte) *WebSocket {
+	ws.a.TB().Helper()
+	return ws.write(BinaryMessage, data)
+}
+
+// Ping 发送 ping 消息
+//
+// 服务端返回的 pong 消息可以通过 [WebSocket.ExpectPong] 进行断言。
+func (ws *WebSocket) Ping(data []byte) *WebSocket {
+	ws.a.TB().Helper()
+	return ws.write(PingMessage, data)
+}
+
+// SendClose 发送关闭帧
+//
+// 服务端的响应可以通过 [WebSocket.ExpectClose] 进行断言。
+func (ws *WebSocket) SendClose(code int, reason string) *WebSocket {
+	ws.a.TB().Helper()
+	ws.closed = true
+	return ws.write(CloseMessage, closePayload(code, reason))
+}
+
+// Read 读取下一条消息
+//
+// 返回消息的类型和内容，类型可能是 [TextMessage]、[BinaryMessage]、[CloseMessage] 和 [PongMessage]。
+// 服务端发送的 ping 消息会自动回复 pong 且不会返回；服务端发送的关闭帧在未主动关闭的情况下也会自动回复。
+//
+// 如果在 timeout 时间内未读取到消息，断言失败并返回 0。
+func (ws *WebSocket) Read(timeout time.Duration, msg ...interface{}) (int, []byte) {
+	ws.a.TB().Helper()
+
+	if ws.conn == nil {
+		return 0, nil
+	}
+
+	ws.a.NotError(ws.conn.SetReadDeadline(time.Now().Add(timeout)))
+	defer ws.conn.SetReadDeadline(time.Time{})
+
+	var typ byte
+	var data []byte
+	for {
+		fin, opcode, payload, err := readFrame(ws.br)
+		if err != nil {
+			ws.a.Assert(false, assert.NewFailure("Read", msg, map[string]interface{}{"err": err}))
+			return 0, nil
+		}
+
+		switch opcode {
+		case PingMessage:
+			ws.write(PongMessage, payload)
+			continue
+		case PongMessage:
+			return PongMessage, payload
+		case CloseMessage:
+			if !ws.closed {
+				ws.closed = true
+				code, _ := parseClosePayload(payload)
+				if code == closeNoStatus {
+					ws.write(CloseMessage, nil)
+				} else {
+					ws.write(CloseMessage, closePayload(code, ""))
+				}
+			}
+			return CloseMessage, payload
+		case 0: // 分片的后续帧
+		default:
+			typ = opcode
+		}
+
+		data = append(data, payload...)
+		if fin {
+			return int(typ), data
+		}
+	}
+}
+
+func (ws *WebSocket) expect(action string, timeout time.Duration, typ int, data []byte, msg []interface{}) *WebSocket {
+	ws.a.TB().Helper()
+
+	t, d := ws.Read(timeout, msg...)
+	if t == 0 {
+		return ws
+	}
+	ws.a.Assert(t == typ && bytes.Equal(d, data), assert.NewFailure(action, msg, map[string]interface{}{"type": t, "v1": string(d), "v2": string(data)}))
+	return ws
+}
+
+// ExpectText 断言在 timeout 时间内接收到的下一条消息为文本消息 s
+func (ws *WebSocket) ExpectText(timeout time.Duration, s string, msg ...interface{}) *WebSocket {
+	ws.a.TB().Helper()
+	return ws.expect("ExpectText", timeout, TextMessage, []byte(s), msg)
+}
+
+// ExpectBinary 断言在 timeout 时间内接收到的下一条消息为二进制消息 data
+func (ws *WebSocket) ExpectBinary(timeout time.Duration, data []byte, msg ...interface{}) *WebSocket {
+	ws.a.TB().Helper()
+	return ws.expect("ExpectBinary", timeout, BinaryMessage, data, msg)
+}
+
+// ExpectPong 断言在 timeout 时间内接收到的下一条消息为内容为 data 的 pong 消息
+func (ws *WebSocket) ExpectPong(timeout time.Duration, data []byte, msg ...interface{}) *WebSocket {
+	ws.a.TB().Helper()
+	return ws.expect("ExpectPong", timeout, PongMessage, data, msg)
+}
+
+// ExpectClose 断言在 timeout 时间内接收到的下一条消息为状态码为 code 的关闭帧
+//
+// 如果关闭帧中未包含状态码，则以 1005 作为其状态码。
+func (ws *WebSocket) ExpectClose(timeout time.Duration, code int, msg ...interface{}) *WebSocket {
+	ws.a.TB().Helper()
+
+	t, d := ws.Read(timeout, msg...)
+	if t == 0 {
+		return ws
+	}
+	c, reason := parseClosePayload(d)
+	ws.a.Assert(t == CloseMessage && c == code, assert.NewFailure("ExpectClose", msg, map[string]interface{}{"type": t, "code": c, "reason": reason, "val": code}))
+	return ws
+}
+
+// Close 关闭连接
+//
+// 仅关闭底层的连接，不会发送关闭帧，如有需要可以先调用 [WebSocket.SendClose]。
+// 如果未手动调用，则在 testing.TB.Cleanup 中自动调用。
+func (ws *WebSocket) Close() {
+	ws.once.Do(func() {
+		if ws.conn != nil {
+			if err := ws.conn.Close(); !errors.Is(err, net.ErrClosed) {
+				ws.a.NotError(err)
+			}
+		}
+	})
+}
+
+func closePayload(code int, reason string) []byte {
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	return append(p, reason...)
+}
+
+func parseClosePayload(p []byte) (int, string) {
+	if len(p) < 2 {
+		return closeNoStatus, ""
+	}
+	return int(binary.BigEndian.Uint16(p)), string(p[2:])
+}
+
+// 写入一个完整的帧
+//
+// 客户端发送的帧 mask 必须为 true，服务端则为 false。
+func writeFrame(w io.Writer, opcode byte, data []byte, mask bool) error {
+	buf := make([]byte, 0, 14+len(data))
+	buf = append(buf, 0x80|opcode)
+
+	var m byte
+	if mask {
+		m = 0x80
+	}
+	switch l := len(data); {
+	case l < 126:
+		buf = append(buf, m|byte(l))
+	case l <= 0xffff:
+		buf = append(buf, m|126, 0, 0)
+		binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(l))
+	default:
+		buf = append(buf, m|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(buf[len(buf)-8:], uint64(l))
+	}
+
+	if !mask {
+		buf = append(buf, data...)
+	} else {
+		key := make([]byte, 4)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		buf = append(buf, key...)
+		for i, b := range data {
+			buf = append(buf, b^key[i%4])
+		}
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// 读取一个帧，如果有掩码，返回的内容是已经解码的。
+func readFrame(r *bufio.Reader) (fin bool, opcode byte, data []byte, err error) {
+	head := make([]byte, 2)
+	if _, err = io.ReadFull(r, head); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+
+	l := uint64(head[1] & 0x7f)
+	switch l {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err = io.ReadFull(r, ext); err != nil {
+			return
+		}
+		l = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err = io.ReadFull(r, ext); err != nil {
+			return
+		}
+		l = binary.BigEndian.Uint64(ext)
+	}
+
+	var key []byte
+	if masked {
+		key = make([]byte, 4)
+		if _, err = io.ReadFull(r, key); err != nil {
+			return
+		}
+	}
+
+	data = make([]byte, l)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return
+	}
+	if masked {
+		for i := range data {
+			data[i] ^= key[i%4]
+		}
+	}
+
+	return
+}
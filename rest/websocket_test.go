@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2014-2024 caixw
+//
+// SPDX-License-Identifier: MIT
+
+package rest
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/issue9/assert/v4"
+)
+
+// 简单的 WebSocket 服务端，原样返回客户端发送的内容。
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("X-Test") != "1" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+	rw.Flush()
+
+	br := bufio.NewReader(rw)
+	for {
+		_, opcode, data, err := readFrame(br)
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case PingMessage:
+			writeFrame(conn, PongMessage, data, false)
+		case PongMessage:
+			if string(data) == "p" {
+				writeFrame(conn, TextMessage, []byte("pong:p"), false)
+			}
+		case CloseMessage:
+			writeFrame(conn, CloseMessage, data, false)
+			return
+		case TextMessage:
+			switch string(data) {
+			case "ping-me":
+				writeFrame(conn, PingMessage, []byte("p"), false)
+			case "fragment":
+				conn.Write([]byte{TextMessage, 2, 'f', 'r'})
+				writeFrame(conn, PingMessage, nil, false)
+				conn.Write([]byte{0x80, 2, 'a', 'g'})
+			case "close":
+				writeFrame(conn, CloseMessage, closePayload(4000, "bye"), false)
+			default:
+				writeFrame(conn, TextMessage, data, false)
+			}
+		default:
+			writeFrame(conn, opcode, data, false)
+		}
+	}
+}
+
+func TestServer_WebSocket(t *testing.T) {
+	a := assert.New(t, false)
+
+	for _, srv := range []*Server{
+		NewServer(a, http.HandlerFunc(wsHandler), nil),
+		NewTLSServer(a, http.HandlerFunc(wsHandler), nil),
+	} {
+		ws := srv.WebSocket("/ws", http.Header{"X-Test": {"1"}})
+		a.Equal(ws.Resp().StatusCode, http.StatusSwitchingProtocols)
+
+		ws.SendText("hello").
+			ExpectText(time.Second, "hello").
+			SendBinary([]byte{1, 2, 3}).
+			ExpectBinary(time.Second, []byte{1, 2, 3}).
+			SendText(string(make([]byte, 70000))).
+			ExpectText(time.Second, string(make([]byte, 70000))).
+			Ping([]byte("abc")).
+			ExpectPong(time.Second, []byte("abc")).
+			SendText("ping-me").
+			ExpectText(time.Second, "pong:p").
+			SendText("fragment").
+			ExpectText(time.Second, "frag").
+			SendClose(1000, "done").
+			ExpectClose(time.Second, 1000)
+		ws.Close()
+		ws.Close()
+
+		srv.WebSocket("/ws", http.Header{"X-Test": {"1"}}).
+			SendText("close").
+			ExpectClose(time.Second, 4000)
+	}
+}
+
+func TestDialWebSocket_fail(t *testing.T) {
+	a := assert.New(t, false)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NotError(err)
+	addr := l.Addr().String()
+	a.NotError(l.Close())
+
+	// 无法连接
+	tb := &failTB{TB: t}
+	ws := DialWebSocket(assert.New(tb, false), "ws://"+addr+"/ws", nil, nil)
+	a.NotNil(ws).Nil(ws.Resp())
+	ws.SendText("hello").
+		ExpectText(time.Second, "hello").
+		SendClose(1000, "").
+		ExpectClose(time.Second, 1000).
+		Close()
+	a.Length(tb.finish(), 1)
+
+	// 握手时服务端直接关闭了连接
+	srv := NewServer(a, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}), nil)
+	tb = &failTB{TB: t}
+	ws = DialWebSocket(assert.New(tb, false), srv.URL()+"/ws", nil, nil)
+	ws.SendText("hello").ExpectText(time.Second, "hello")
+	a.Length(tb.finish(), 1)
+}
+
+func TestFrame(t *testing.T) {
+	a := assert.New(t, false)
+
+	code, reason := parseClosePayload(closePayload(1001, "reason"))
+	a.Equal(code, 1001).Equal(reason, "reason")
+
+	code, reason = parseClosePayload(nil)
+	a.Equal(code, closeNoStatus).Empty(reason)
+}